@@ -0,0 +1,155 @@
+package zimage
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// ColorHashHSL derives a background colour from a string, and a foreground
+// colour with enough contrast to display text on top of it. Both are returned
+// as "#rrggbb".
+//
+// The hash of s is mapped to a hue, with a fixed saturation and lightness. This
+// gives visually pleasant colours that are well-spread even for similar
+// inputs such as "user1" and "user2".
+//
+// The foreground is black or white, depending on the luma of the background.
+func ColorHashHSL(s string) (bg, fg string) {
+	return ColorHashPalette(s, "#000000", "#ffffff", 128)
+}
+
+// ColorHashPalette works like ColorHashHSL, but lets the caller choose the
+// foreground colours.
+//
+// The foreground is light if the luma of the background is lower than
+// threshold, and dark otherwise. The luma is from 0 to 255; ColorHashHSL uses a
+// threshold of 128.
+func ColorHashPalette(s, dark, light string, threshold float64) (bg, fg string) {
+	r, g, b := colorHash(s)
+	bg = FormatHex(r, g, b)
+	if Luma(r, g, b) < threshold {
+		return bg, light
+	}
+	return bg, dark
+}
+
+// colorHash maps the MD5 hash of s to a hue, with a fixed saturation and
+// lightness.
+func colorHash(s string) (uint8, uint8, uint8) {
+	sum := md5.Sum([]byte(s))
+	hue := float64(binary.BigEndian.Uint32(sum[:4])%3600) / 10
+	return hslToRGB(hue, 0.65, 0.5)
+}
+
+// Luma calculates the perceived brightness of a colour, using the BT.601
+// weights. The return value is from 0 (black) to 255 (white).
+func Luma(r, g, b uint8) float64 {
+	return 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+}
+
+// ContrastRatio calculates the contrast ratio between two colours, as defined
+// by WCAG 2. The return value is from 1 (no contrast) to 21 (black on white).
+//
+// WCAG requires a ratio of at least 4.5 for normal text (level AA), or 7 for
+// enhanced contrast (level AAA).
+//
+// The alpha channel is ignored.
+func ContrastRatio(fg, bg color.Color) float64 {
+	l1, l2 := luminance(fg), luminance(bg)
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+	return (l1 + 0.05) / (l2 + 0.05)
+}
+
+// PickReadableText picks the colour from candidates with the highest contrast
+// ratio to bg.
+//
+// If no candidates are given it picks either black or white.
+func PickReadableText(bg color.Color, candidates ...color.Color) color.Color {
+	if len(candidates) == 0 {
+		candidates = []color.Color{color.Black, color.White}
+	}
+
+	var (
+		best      color.Color
+		bestRatio float64
+	)
+	for _, c := range candidates {
+		if r := ContrastRatio(c, bg); best == nil || r > bestRatio {
+			best, bestRatio = c, r
+		}
+	}
+	return best
+}
+
+// ParseHex parses a hex colour in the form of "#rgb", "#rrggbb", or
+// "#rrggbbaa". The alpha channel is accepted but ignored.
+func ParseHex(s string) (r, g, b uint8, err error) {
+	if !strings.HasPrefix(s, "#") {
+		return 0, 0, 0, fmt.Errorf("zimage.ParseHex: %q doesn't start with '#'", s)
+	}
+
+	h := s[1:]
+	switch len(h) {
+	case 3:
+		h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+	case 6, 8:
+	default:
+		return 0, 0, 0, fmt.Errorf("zimage.ParseHex: %q has wrong length; must be #rgb, #rrggbb, or #rrggbbaa", s)
+	}
+
+	c, err := hex.DecodeString(h)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("zimage.ParseHex: %q contains invalid hex digits", s)
+	}
+	return c[0], c[1], c[2], nil
+}
+
+// FormatHex formats a colour as "#rrggbb".
+func FormatHex(r, g, b uint8) string {
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
+
+// luminance gets the relative luminance as defined by WCAG 2.
+func luminance(c color.Color) float64 {
+	r, g, b, _ := color.NRGBAModel.Convert(c).RGBA()
+	lin := func(v uint32) float64 {
+		f := float64(v) / 0xffff
+		if f <= 0.03928 {
+			return f / 12.92
+		}
+		return math.Pow((f+0.055)/1.055, 2.4)
+	}
+	return 0.2126*lin(r) + 0.7152*lin(g) + 0.0722*lin(b)
+}
+
+// hslToRGB converts a hue (0-360), saturation (0-1), and lightness (0-1) to RGB.
+func hslToRGB(h, s, l float64) (uint8, uint8, uint8) {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return uint8(math.Round((r + m) * 255)), uint8(math.Round((g + m) * 255)),
+		uint8(math.Round((b + m) * 255))
+}
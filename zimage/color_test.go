@@ -0,0 +1,271 @@
+package zimage
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"math"
+	"testing"
+
+	"zgo.at/zstd/ztest"
+)
+
+func TestColorHashHSL(t *testing.T) {
+	hue := func(s string) float64 {
+		sum := md5.Sum([]byte(s))
+		return float64(binary.BigEndian.Uint32(sum[:4])%3600) / 10
+	}
+
+	tests := []string{"", "user1", "martin", "汉语", "a"}
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			bg, fg := ColorHashHSL(tt)
+			bg2, fg2 := ColorHashHSL(tt)
+			if bg != bg2 || fg != fg2 {
+				t.Errorf("not deterministic: %s %s; %s %s", bg, fg, bg2, fg2)
+			}
+			if len(bg) != 7 || bg[0] != '#' {
+				t.Errorf("wrong bg: %q", bg)
+			}
+			if fg != "#000000" && fg != "#ffffff" {
+				t.Errorf("wrong fg: %q", fg)
+			}
+		})
+	}
+
+	t.Run("spread", func(t *testing.T) {
+		for i := 1; i < 10; i++ {
+			a, b := fmt.Sprintf("user%d", i), fmt.Sprintf("user%d", i+1)
+			bgA, _ := ColorHashHSL(a)
+			bgB, _ := ColorHashHSL(b)
+			if bgA == bgB {
+				t.Errorf("%s and %s have the same colour %s", a, b, bgA)
+			}
+
+			d := math.Abs(hue(a) - hue(b))
+			if d > 180 {
+				d = 360 - d
+			}
+			if d < 10 {
+				t.Errorf("hues of %s and %s too close: %.1f", a, b, d)
+			}
+		}
+	})
+}
+
+func TestColorHashPalette(t *testing.T) {
+	const dark, light = "#112233", "#eeddcc"
+
+	for _, s := range []string{"", "user1", "martin", "汉语", "a"} {
+		t.Run(s, func(t *testing.T) {
+			bg, fg := ColorHashPalette(s, "#000000", "#ffffff", 128)
+			bgHSL, fgHSL := ColorHashHSL(s)
+			if bg != bgHSL || fg != fgHSL {
+				t.Errorf("not the same as ColorHashHSL\nout:  %s %s\nwant: %s %s\n", bg, fg, bgHSL, fgHSL)
+			}
+
+			r, g, b, err := ParseHex(bg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			l := Luma(r, g, b)
+
+			tests := []struct {
+				threshold float64
+				want      string
+			}{
+				{0, dark},
+				{math.Nextafter(l, 0), dark},
+				{l, dark}, // Luma isn't lower than the threshold.
+				{math.Nextafter(l, 256), light},
+				{256, light},
+			}
+			for _, tt := range tests {
+				out, fg := ColorHashPalette(s, dark, light, tt.threshold)
+				if out != bg {
+					t.Errorf("background changed: %s", out)
+				}
+				if fg != tt.want {
+					t.Errorf("threshold %v (luma %v)\nout:  %s\nwant: %s\n", tt.threshold, l, fg, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestHSLToRGB(t *testing.T) {
+	tests := []struct {
+		h, s, l float64
+		want    string
+	}{
+		{0, 0, 0, "#000000"},
+		{0, 0, 1, "#ffffff"},
+		{0, 0, 0.5, "#808080"},
+		{0, 1, 0.5, "#ff0000"},
+		{60, 1, 0.5, "#ffff00"},
+		{120, 1, 0.5, "#00ff00"},
+		{180, 1, 0.5, "#00ffff"},
+		{240, 1, 0.5, "#0000ff"},
+		{300, 1, 0.5, "#ff00ff"},
+		{210, 0.65, 0.5, "#2d80d2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			r, g, b := hslToRGB(tt.h, tt.s, tt.l)
+			out := fmt.Sprintf("#%02x%02x%02x", r, g, b)
+			if out != tt.want {
+				t.Errorf("\nout:  %s\nwant: %s\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestLuma(t *testing.T) {
+	tests := []struct {
+		r, g, b uint8
+		want    float64
+	}{
+		{0, 0, 0, 0},
+		{255, 255, 255, 255},
+		{255, 0, 0, 76.245},
+		{0, 255, 0, 149.685},
+		{0, 0, 255, 29.07},
+		{128, 128, 128, 128},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d,%d,%d", tt.r, tt.g, tt.b), func(t *testing.T) {
+			out := Luma(tt.r, tt.g, tt.b)
+			if math.Abs(out-tt.want) > 0.001 {
+				t.Errorf("\nout:  %f\nwant: %f\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestContrastRatio(t *testing.T) {
+	rgb := func(r, g, b uint8) color.Color { return color.RGBA{r, g, b, 255} }
+
+	tests := []struct {
+		fg, bg color.Color
+		want   float64
+	}{
+		{color.Black, color.White, 21},
+		{color.White, color.Black, 21},
+		{color.White, color.White, 1},
+		{rgb(0x77, 0x77, 0x77), color.White, 4.48},
+		{rgb(0x76, 0x76, 0x76), color.White, 4.54},
+		{rgb(0, 0, 0xff), color.White, 8.59},
+		{rgb(0xff, 0, 0), color.White, 4.00},
+		{rgb(0, 0x80, 0), color.White, 5.14},
+		{color.Gray{0x80}, color.Black, 5.32},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v on %v", tt.fg, tt.bg), func(t *testing.T) {
+			out := ContrastRatio(tt.fg, tt.bg)
+			if math.Abs(out-tt.want) > 0.005 {
+				t.Errorf("\nout:  %f\nwant: %f\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestPickReadableText(t *testing.T) {
+	var (
+		navy   = color.RGBA{0, 0, 0x80, 255}
+		yellow = color.RGBA{0xff, 0xff, 0, 255}
+		grey   = color.RGBA{0x80, 0x80, 0x80, 255}
+	)
+
+	tests := []struct {
+		bg         color.Color
+		candidates []color.Color
+		want       color.Color
+	}{
+		{color.White, nil, color.Black},
+		{color.Black, nil, color.White},
+		{navy, nil, color.White},
+		{yellow, nil, color.Black},
+		{navy, []color.Color{grey, yellow}, yellow},
+		{yellow, []color.Color{grey, navy}, navy},
+		{color.White, []color.Color{grey}, grey},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v", tt.bg), func(t *testing.T) {
+			out := PickReadableText(tt.bg, tt.candidates...)
+			if out != tt.want {
+				t.Errorf("\nout:  %v\nwant: %v\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHex(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    [3]uint8
+		wantErr string
+	}{
+		{"#000000", [3]uint8{0, 0, 0}, ""},
+		{"#ffffff", [3]uint8{255, 255, 255}, ""},
+		{"#FF8000", [3]uint8{255, 128, 0}, ""},
+		{"#2d80d2", [3]uint8{0x2d, 0x80, 0xd2}, ""},
+		{"#f80", [3]uint8{0xff, 0x88, 0x00}, ""},
+		{"#ABC", [3]uint8{0xaa, 0xbb, 0xcc}, ""},
+		{"#2d80d280", [3]uint8{0x2d, 0x80, 0xd2}, ""},
+
+		{"", [3]uint8{}, "doesn't start with '#'"},
+		{"ffffff", [3]uint8{}, "doesn't start with '#'"},
+		{"#", [3]uint8{}, "wrong length"},
+		{"#ffff", [3]uint8{}, "wrong length"},
+		{"#fffffff", [3]uint8{}, "wrong length"},
+		{"#gggggg", [3]uint8{}, "invalid hex digits"},
+		{"#ggg", [3]uint8{}, "invalid hex digits"},
+		{"#ff ff ", [3]uint8{}, "invalid hex digits"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			r, g, b, err := ParseHex(tt.in)
+			if !ztest.ErrorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nout:  %v\nwant: %v", err, tt.wantErr)
+			}
+			if out := [3]uint8{r, g, b}; out != tt.want {
+				t.Errorf("\nout:  %v\nwant: %v\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatHex(t *testing.T) {
+	tests := []struct {
+		r, g, b uint8
+		want    string
+	}{
+		{0, 0, 0, "#000000"},
+		{255, 255, 255, "#ffffff"},
+		{0x2d, 0x80, 0xd2, "#2d80d2"},
+		{1, 2, 3, "#010203"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			out := FormatHex(tt.r, tt.g, tt.b)
+			if out != tt.want {
+				t.Errorf("\nout:  %s\nwant: %s\n", out, tt.want)
+			}
+
+			r, g, b, err := ParseHex(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r != tt.r || g != tt.g || b != tt.b {
+				t.Errorf("round-trip: %d %d %d", r, g, b)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package zimage
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+)
+
+// EncodeDataURI encodes img as a data URI, such as
+// "data:image/png;base64,iVBORw0KGgo…", which can be used in the src attribute
+// of an HTML <img>.
+//
+// The format can be "png", "jpeg" (or "jpg"), or "gif". JPEG images are
+// encoded with the default quality.
+func EncodeDataURI(img image.Image, format string) (string, error) {
+	var (
+		buf bytes.Buffer
+		ct  string
+		err error
+	)
+	switch strings.ToLower(format) {
+	case "png":
+		ct, err = "image/png", png.Encode(&buf, img)
+	case "jpeg", "jpg":
+		ct, err = "image/jpeg", jpeg.Encode(&buf, img, nil)
+	case "gif":
+		ct, err = "image/gif", gif.Encode(&buf, img, nil)
+	default:
+		return "", fmt.Errorf("zimage.EncodeDataURI: unsupported format %q", format)
+	}
+	if err != nil {
+		return "", fmt.Errorf("zimage.EncodeDataURI: %w", err)
+	}
+
+	return "data:" + ct + ";base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
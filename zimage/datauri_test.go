@@ -0,0 +1,67 @@
+package zimage
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"zgo.at/zstd/ztest"
+)
+
+func TestEncodeDataURI(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 12, 7))
+	for x := 0; x < 12; x++ {
+		img.Set(x, 3, color.RGBA{0xff, 0, 0, 0xff})
+	}
+
+	tests := []struct {
+		format, ct, decoded string
+		wantErr             string
+	}{
+		{"png", "image/png", "png", ""},
+		{"PNG", "image/png", "png", ""},
+		{"jpeg", "image/jpeg", "jpeg", ""},
+		{"jpg", "image/jpeg", "jpeg", ""},
+		{"gif", "image/gif", "gif", ""},
+		{"webp", "", "", `unsupported format "webp"`},
+		{"", "", "", `unsupported format ""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			out, err := EncodeDataURI(img, tt.format)
+			if !ztest.ErrorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nout:  %v\nwant: %v\n", err, tt.wantErr)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+
+			prefix := "data:" + tt.ct + ";base64,"
+			if !strings.HasPrefix(out, prefix) {
+				t.Fatalf("wrong prefix: %.40s", out)
+			}
+			data, err := base64.StdEncoding.DecodeString(out[len(prefix):])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ct := DetectImage(data); ct != tt.ct {
+				t.Errorf("DetectImage: %q", ct)
+			}
+
+			dec, format, err := image.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != tt.decoded {
+				t.Errorf("wrong format: %q", format)
+			}
+			if dec.Bounds() != img.Bounds() {
+				t.Errorf("wrong bounds: %v", dec.Bounds())
+			}
+		})
+	}
+}
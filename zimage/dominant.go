@@ -0,0 +1,97 @@
+package zimage
+
+import (
+	"image"
+	"image/color"
+	"sort"
+)
+
+// DominantColor finds the most prominent colour in img.
+//
+// This is the same as the first colour returned by Palette; it returns
+// color.Transparent if the image has no opaque pixels.
+func DominantColor(img image.Image) color.Color {
+	p := Palette(img, 1)
+	if len(p) == 0 {
+		return color.Transparent
+	}
+	return p[0]
+}
+
+// Palette finds the n most prominent colours in img, most common first.
+//
+// Similar colours are bucketed together, and the returned colour is the
+// average of all pixels in the bucket. Pixels that are mostly transparent are
+// ignored. Large images are sampled rather than looking at every pixel.
+//
+// Fewer than n colours are returned if the image doesn't have enough distinct
+// colours. It returns nil if n is 0 or lower.
+func Palette(img image.Image, n int) []color.Color {
+	if n <= 0 {
+		return nil
+	}
+
+	const (
+		maxSamples = 128    // Sample at most maxSamples² pixels.
+		minAlpha   = 0x8000 // Ignore pixels that are more than half transparent.
+		shift      = 16 - 4 // Bucket on the 4 most significant bits per channel.
+	)
+
+	type bucket struct {
+		r, g, b, n uint64
+		key        uint32
+	}
+	var (
+		buckets = make(map[uint32]*bucket)
+		bounds  = img.Bounds()
+		stepX   = bounds.Dx()/maxSamples + 1
+		stepY   = bounds.Dy()/maxSamples + 1
+	)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stepY {
+		for x := bounds.Min.X; x < bounds.Max.X; x += stepX {
+			r, g, b, a := img.At(x, y).RGBA()
+			if a < minAlpha {
+				continue
+			}
+			if a != 0xffff { // Undo alpha-premultiplication.
+				r, g, b = r*0xffff/a, g*0xffff/a, b*0xffff/a
+			}
+
+			key := r>>shift<<8 | g>>shift<<4 | b>>shift
+			bk, ok := buckets[key]
+			if !ok {
+				bk = &bucket{key: key}
+				buckets[key] = bk
+			}
+			bk.r += uint64(r)
+			bk.g += uint64(g)
+			bk.b += uint64(b)
+			bk.n++
+		}
+	}
+
+	sorted := make([]*bucket, 0, len(buckets))
+	for _, bk := range buckets {
+		sorted = append(sorted, bk)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].n == sorted[j].n {
+			return sorted[i].key < sorted[j].key
+		}
+		return sorted[i].n > sorted[j].n
+	})
+	if n < len(sorted) {
+		sorted = sorted[:n]
+	}
+
+	p := make([]color.Color, 0, len(sorted))
+	for _, bk := range sorted {
+		p = append(p, color.RGBA64{
+			R: uint16(bk.r / bk.n),
+			G: uint16(bk.g / bk.n),
+			B: uint16(bk.b / bk.n),
+			A: 0xffff,
+		})
+	}
+	return p
+}
@@ -0,0 +1,90 @@
+package zimage
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestDominantColor(t *testing.T) {
+	var (
+		red   = color.RGBA{0xff, 0, 0, 0xff}
+		blue  = color.RGBA{0, 0, 0xff, 0xff}
+		green = color.RGBA{0, 0x80, 0, 0xff}
+	)
+
+	// Fill the image from the top with colours covering the given percentage.
+	img := func(w, h int, fill ...interface{}) *image.RGBA {
+		m := image.NewRGBA(image.Rect(0, 0, w, h))
+		y := 0
+		for i := 0; i < len(fill); i += 2 {
+			end := y + h*fill[i+1].(int)/100
+			draw.Draw(m, image.Rect(0, y, w, end), image.NewUniform(fill[i].(color.Color)), image.Point{}, draw.Src)
+			y = end
+		}
+		return m
+	}
+
+	tests := []struct {
+		in      image.Image
+		want    color.Color
+		palette []color.Color
+	}{
+		{image.NewRGBA(image.Rect(0, 0, 0, 0)), color.Transparent, []color.Color{}},
+		{image.NewRGBA(image.Rect(0, 0, 10, 10)), color.Transparent, []color.Color{}},
+		{img(10, 10, red, 100), red, []color.Color{red}},
+		{img(10, 10, red, 60, blue, 30, green, 10), red, []color.Color{red, blue, green}},
+		{img(10, 10, green, 20, blue, 50, red, 30), blue, []color.Color{blue, red, green}},
+
+		// Transparent pixels are ignored, even if they're the majority.
+		{img(10, 10, color.Transparent, 80, green, 20), green, []color.Color{green}},
+		{img(10, 10, color.RGBA{0, 0, 0x10, 0x10}, 80, green, 20), green, []color.Color{green}},
+
+		// Similar colours are counted as the same.
+		{img(10, 10, red, 40, color.RGBA{0, 0, 0xfe, 0xff}, 30, color.RGBA{0, 0, 0xfc, 0xff}, 30),
+			color.RGBA{0, 0, 0xfd, 0xff}, []color.Color{color.RGBA{0, 0, 0xfd, 0xff}, red}},
+
+		// Large images are sampled.
+		{img(3000, 2000, red, 30, blue, 70), blue, []color.Color{blue, red}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			out := DominantColor(tt.in)
+			if !sameColor(out, tt.want) {
+				t.Errorf("\nout:  %v\nwant: %v\n", out, tt.want)
+			}
+
+			p := Palette(tt.in, 5)
+			if len(p) != len(tt.palette) {
+				t.Fatalf("palette\nout:  %v\nwant: %v\n", p, tt.palette)
+			}
+			for j := range p {
+				if !sameColor(p[j], tt.palette[j]) {
+					t.Errorf("palette\nout:  %v\nwant: %v\n", p, tt.palette)
+				}
+			}
+		})
+	}
+
+	t.Run("n", func(t *testing.T) {
+		m := img(10, 10, red, 60, blue, 30, green, 10)
+		p := Palette(m, 2)
+		if len(p) != 2 || !sameColor(p[0], red) || !sameColor(p[1], blue) {
+			t.Errorf("\nout:  %v\n", p)
+		}
+
+		for _, n := range []int{0, -1} {
+			if p := Palette(m, n); p != nil {
+				t.Errorf("n=%d\nout:  %v\n", n, p)
+			}
+		}
+	})
+}
+
+// sameColor reports if a and b are identical when converted to 8-bit RGBA.
+func sameColor(a, b color.Color) bool {
+	return color.RGBAModel.Convert(a) == color.RGBAModel.Convert(b)
+}
@@ -0,0 +1,49 @@
+package zimage
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Grayscale converts img to greyscale, using the BT.601 weights from Luma.
+//
+// The alpha channel is dropped; transparent pixels are treated as if they're
+// drawn on a black background, which is the same as color.GrayModel.
+func Grayscale(img image.Image) image.Image {
+	b := img.Bounds()
+	gray := image.NewGray(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			l := Luma(uint8(r>>8), uint8(g>>8), uint8(bl>>8))
+			gray.SetGray(x, y, color.Gray{Y: uint8(math.Round(l))})
+		}
+	}
+	return gray
+}
+
+// AverageColor calculates the average colour of all pixels in img.
+//
+// Transparent pixels contribute less to the colour, and the alpha of the
+// result is the average alpha. It returns color.Transparent for an empty
+// image.
+func AverageColor(img image.Image) color.Color {
+	var (
+		b           = img.Bounds()
+		r, g, bl, a uint64
+	)
+	if b.Empty() {
+		return color.Transparent
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			pr, pg, pb, pa := img.At(x, y).RGBA()
+			r, g, bl, a = r+uint64(pr), g+uint64(pg), bl+uint64(pb), a+uint64(pa)
+		}
+	}
+
+	n := uint64(b.Dx()) * uint64(b.Dy())
+	avg := func(v uint64) uint16 { return uint16((v + n/2) / n) }
+	return color.RGBA64{R: avg(r), G: avg(g), B: avg(bl), A: avg(a)}
+}
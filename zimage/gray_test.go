@@ -0,0 +1,70 @@
+package zimage
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestGrayscale(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(1, 1, 4, 3))
+	px := []struct {
+		in   color.NRGBA
+		want uint8
+	}{
+		{color.NRGBA{0, 0, 0, 0xff}, 0},
+		{color.NRGBA{0xff, 0xff, 0xff, 0xff}, 0xff},
+		{color.NRGBA{0xff, 0, 0, 0xff}, 76},
+		{color.NRGBA{0, 0xff, 0, 0xff}, 150},
+		{color.NRGBA{0, 0, 0xff, 0xff}, 29},
+		{color.NRGBA{0xff, 0xff, 0xff, 0x80}, 0x80},
+	}
+	for i, p := range px {
+		img.SetNRGBA(1+i%3, 1+i/3, p.in)
+	}
+
+	out := Grayscale(img)
+	if out.Bounds() != img.Bounds() {
+		t.Fatalf("wrong bounds: %v", out.Bounds())
+	}
+	for i, p := range px {
+		g := color.GrayModel.Convert(out.At(1+i%3, 1+i/3)).(color.Gray)
+		if g.Y != p.want {
+			t.Errorf("pixel %d\nout:  %d\nwant: %d\n", i, g.Y, p.want)
+		}
+	}
+}
+
+func TestAverageColor(t *testing.T) {
+	tests := []struct {
+		name string
+		px   []color.Color
+		want color.RGBA
+	}{
+		{"empty", nil, color.RGBA{}},
+		{"single", []color.Color{color.RGBA{10, 20, 30, 0xff}},
+			color.RGBA{10, 20, 30, 0xff}},
+		{"black-white", []color.Color{color.White, color.Black},
+			color.RGBA{0x80, 0x80, 0x80, 0xff}},
+		{"rgb", []color.Color{
+			color.RGBA{0xff, 0, 0, 0xff}, color.RGBA{0, 0xff, 0, 0xff},
+			color.RGBA{0, 0, 0xff, 0xff}, color.RGBA{0, 0, 0xff, 0xff}},
+			color.RGBA{0x40, 0x40, 0x80, 0xff}},
+		{"transparent", []color.Color{color.RGBA{0xff, 0, 0, 0xff}, color.Transparent},
+			color.RGBA{0x80, 0, 0, 0x80}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rect(0, 0, len(tt.px), 1))
+			for i, c := range tt.px {
+				img.Set(i, 0, c)
+			}
+
+			out := color.RGBAModel.Convert(AverageColor(img))
+			if out != tt.want {
+				t.Errorf("\nout:  %v\nwant: %v\n", out, tt.want)
+			}
+		})
+	}
+}
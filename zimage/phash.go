@@ -0,0 +1,95 @@
+package zimage
+
+import (
+	"errors"
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// PHash calculates a 64-bit perceptual hash of img.
+//
+// Similar looking images have similar hashes, even if they're resized or
+// slightly modified. Use HammingDistance to compare two hashes; a distance of
+// about 10 or lower usually means the images are near-duplicates.
+//
+// This uses the DCT-based algorithm: the image is scaled to 32×32 greyscale,
+// and the hash is derived from the lowest 8×8 frequencies of the discrete
+// cosine transform.
+func PHash(img image.Image) (uint64, error) {
+	if img.Bounds().Empty() {
+		return 0, errors.New("zimage.PHash: image is empty")
+	}
+
+	const size, low = 32, 8
+
+	// Average the luma of all pixels in every cell; this is more robust than
+	// interpolating when scaling down large images.
+	var (
+		px [size][size]float64
+		n  [size][size]float64
+		b  = img.Bounds()
+	)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		cy := (y - b.Min.Y) * size / b.Dy()
+		for x := b.Min.X; x < b.Max.X; x++ {
+			cx := (x - b.Min.X) * size / b.Dx()
+			r, g, bl, _ := img.At(x, y).RGBA()
+			px[cy][cx] += Luma(uint8(r>>8), uint8(g>>8), uint8(bl>>8))
+			n[cy][cx]++
+		}
+	}
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			// Images smaller than 32×32 have empty cells; copy the value from
+			// the cell that the nearest source pixel was added to.
+			if n[y][x] == 0 {
+				sy, sx := y*b.Dy()/size, x*b.Dx()/size
+				px[y][x] = px[sy*size/b.Dy()][sx*size/b.Dx()]
+				continue
+			}
+			px[y][x] /= n[y][x]
+		}
+	}
+
+	// Only the lowest frequencies are used, so there's no need for a full DCT.
+	var cos [low][size]float64
+	for u := 0; u < low; u++ {
+		for x := 0; x < size; x++ {
+			cos[u][x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * size))
+		}
+	}
+	var dct [low * low]float64
+	for v := 0; v < low; v++ {
+		for u := 0; u < low; u++ {
+			var sum float64
+			for y := 0; y < size; y++ {
+				for x := 0; x < size; x++ {
+					sum += px[y][x] * cos[u][x] * cos[v][y]
+				}
+			}
+			dct[v*low+u] = sum
+		}
+	}
+
+	// Compare against the median, excluding the DC term (the average
+	// brightness), which would otherwise skew it.
+	sorted := make([]float64, len(dct)-1)
+	copy(sorted, dct[1:])
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+
+	var hash uint64
+	for i, c := range dct {
+		if c > median {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash, nil
+}
+
+// HammingDistance gets the number of bits that differ between a and b.
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
@@ -0,0 +1,112 @@
+package zimage
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	"math"
+	"testing"
+
+	testimg "zgo.at/zstd/ztest/image"
+)
+
+func TestPHash(t *testing.T) {
+	pattern := func(w, h int, f func(x, y float64) uint8) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				v := f(float64(x)/float64(w), float64(y)/float64(h))
+				img.SetRGBA(x, y, color.RGBA{v, v / 2, 255 - v, 255})
+			}
+		}
+		return img
+	}
+	waves := func(x, y float64) uint8 {
+		return uint8(127 + 60*math.Sin(x*7+1) + 40*math.Cos(y*11) + 20*math.Sin((x+y)*17))
+	}
+
+	img := pattern(200, 150, waves)
+	hash := func(img image.Image) uint64 {
+		t.Helper()
+		h, err := PHash(img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	h := hash(img)
+
+	t.Run("identical", func(t *testing.T) {
+		if h2 := hash(pattern(200, 150, waves)); h2 != h {
+			t.Errorf("%016x != %016x", h2, h)
+		}
+	})
+
+	t.Run("resized", func(t *testing.T) {
+		if d := HammingDistance(h, hash(pattern(400, 300, waves))); d > 4 {
+			t.Errorf("distance too large: %d", d)
+		}
+	})
+
+	t.Run("modified", func(t *testing.T) {
+		mod := pattern(200, 150, waves)
+		for y := 20; y < 30; y++ {
+			for x := 20; x < 30; x++ {
+				mod.SetRGBA(x, y, color.RGBA{255, 255, 255, 255})
+			}
+		}
+		if d := HammingDistance(h, hash(mod)); d > 10 {
+			t.Errorf("distance too large: %d", d)
+		}
+	})
+
+	t.Run("different", func(t *testing.T) {
+		other := hash(pattern(200, 150, func(x, y float64) uint8 {
+			return uint8(255 * x * y)
+		}))
+		if d := HammingDistance(h, other); d < 20 {
+			t.Errorf("distance too small: %d", d)
+		}
+
+		jpeg, _, err := image.Decode(bytes.NewReader(testimg.JPEG))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := HammingDistance(h, hash(jpeg)); d < 20 {
+			t.Errorf("distance too small: %d", d)
+		}
+	})
+
+	t.Run("small", func(t *testing.T) {
+		if d := HammingDistance(h, hash(pattern(20, 15, waves))); d > 10 {
+			t.Errorf("distance too large: %d", d)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		_, err := PHash(image.NewRGBA(image.Rect(0, 0, 0, 0)))
+		if err == nil {
+			t.Error("err is nil")
+		}
+	})
+}
+
+func TestHammingDistance(t *testing.T) {
+	tests := []struct {
+		a, b uint64
+		want int
+	}{
+		{0, 0, 0},
+		{0, 1, 1},
+		{0b1010, 0b0101, 4},
+		{math.MaxUint64, 0, 64},
+		{math.MaxUint64, math.MaxUint64, 0},
+	}
+
+	for _, tt := range tests {
+		if out := HammingDistance(tt.a, tt.b); out != tt.want {
+			t.Errorf("HammingDistance(%b, %b) = %d; want %d", tt.a, tt.b, out, tt.want)
+		}
+	}
+}
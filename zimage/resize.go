@@ -0,0 +1,94 @@
+package zimage
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Resize scales src down to fit within maxW×maxH, preserving the aspect ratio.
+//
+// A maxW or maxH of 0 or lower means that dimension isn't constrained. Images
+// that already fit are never scaled up; src is returned as-is in that case.
+// Use ResizeExact to scale up.
+//
+// Bilinear interpolation is used to scale the image.
+func Resize(src image.Image, maxW, maxH int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return src
+	}
+
+	scale := 1.0
+	if maxW > 0 {
+		scale = math.Min(scale, float64(maxW)/float64(w))
+	}
+	if maxH > 0 {
+		scale = math.Min(scale, float64(maxH)/float64(h))
+	}
+	if scale >= 1 {
+		return src
+	}
+
+	nw := int(math.Max(1, math.Round(float64(w)*scale)))
+	nh := int(math.Max(1, math.Round(float64(h)*scale)))
+	return ResizeExact(src, nw, nh)
+}
+
+// ResizeExact scales src to exactly w×h, without preserving the aspect ratio.
+//
+// Bilinear interpolation is used to scale the image.
+func ResizeExact(src image.Image, w, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	sb := src.Bounds()
+	sw, sh := sb.Dx(), sb.Dy()
+	if sw == 0 || sh == 0 || w <= 0 || h <= 0 {
+		return dst
+	}
+
+	// Map the centre of the destination pixel to the source, and interpolate
+	// between the four surrounding source pixels.
+	coord := func(d, dSize, sSize int) (int, int, float64) {
+		s := (float64(d)+0.5)*float64(sSize)/float64(dSize) - 0.5
+		s = math.Max(0, math.Min(s, float64(sSize-1)))
+		s0 := int(s)
+		s1 := s0 + 1
+		if s1 > sSize-1 {
+			s1 = sSize - 1
+		}
+		return s0, s1, s - float64(s0)
+	}
+
+	for y := 0; y < h; y++ {
+		y0, y1, fy := coord(y, h, sh)
+		for x := 0; x < w; x++ {
+			x0, x1, fx := coord(x, w, sw)
+
+			var c [4]float64
+			for _, p := range []struct {
+				x, y   int
+				weight float64
+			}{
+				{x0, y0, (1 - fx) * (1 - fy)},
+				{x1, y0, fx * (1 - fy)},
+				{x0, y1, (1 - fx) * fy},
+				{x1, y1, fx * fy},
+			} {
+				r, g, b, a := src.At(sb.Min.X+p.x, sb.Min.Y+p.y).RGBA()
+				c[0] += float64(r) * p.weight
+				c[1] += float64(g) * p.weight
+				c[2] += float64(b) * p.weight
+				c[3] += float64(a) * p.weight
+			}
+
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(math.Round(c[0] / 257)),
+				G: uint8(math.Round(c[1] / 257)),
+				B: uint8(math.Round(c[2] / 257)),
+				A: uint8(math.Round(c[3] / 257)),
+			})
+		}
+	}
+	return dst
+}
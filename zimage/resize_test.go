@@ -0,0 +1,98 @@
+package zimage
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		w, h, maxW, maxH int
+		wantW, wantH     int
+	}{
+		{400, 200, 100, 100, 100, 50},  // Landscape
+		{200, 400, 100, 100, 50, 100},  // Portrait
+		{300, 300, 100, 50, 50, 50},    // Square
+		{400, 300, 200, 0, 200, 150},   // Unconstrained height
+		{400, 300, 0, 30, 40, 30},      // Unconstrained width
+		{333, 100, 100, 100, 100, 30},  // Rounding
+		{1000, 1, 10, 10, 10, 1},       // Minimum of 1px
+		{50, 50, 100, 100, 50, 50},     // Never scale up
+		{50, 50, 0, 0, 50, 50},         // No constraints
+		{640, 480, 640, 480, 640, 480}, // Exact fit
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			src := image.NewRGBA(image.Rect(0, 0, tt.w, tt.h))
+			out := Resize(src, tt.maxW, tt.maxH).Bounds()
+			if out.Dx() != tt.wantW || out.Dy() != tt.wantH {
+				t.Fatalf("%dx%d → %dx%d; want %dx%d", tt.w, tt.h, out.Dx(), out.Dy(), tt.wantW, tt.wantH)
+			}
+
+			// Aspect ratio should be kept within a pixel.
+			d := float64(tt.w)/float64(tt.h)*float64(out.Dy()) - float64(out.Dx())
+			if tt.w > tt.h {
+				d = float64(tt.h)/float64(tt.w)*float64(out.Dx()) - float64(out.Dy())
+			}
+			if d > 1 || d < -1 {
+				t.Errorf("aspect ratio not kept; off by %f pixels", d)
+			}
+		})
+	}
+}
+
+func TestResizeExact(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+
+	// Left half red, right half blue, with an offset origin.
+	src := image.NewRGBA(image.Rect(10, 10, 18, 14))
+	for y := 10; y < 14; y++ {
+		for x := 10; x < 18; x++ {
+			if x < 14 {
+				src.Set(x, y, red)
+			} else {
+				src.Set(x, y, blue)
+			}
+		}
+	}
+
+	t.Run("down", func(t *testing.T) {
+		out := ResizeExact(src, 4, 2)
+		if out.Bounds() != image.Rect(0, 0, 4, 2) {
+			t.Fatalf("wrong bounds: %v", out.Bounds())
+		}
+		if c := out.RGBAAt(0, 0); c != red {
+			t.Errorf("left: %v", c)
+		}
+		if c := out.RGBAAt(3, 1); c != blue {
+			t.Errorf("right: %v", c)
+		}
+	})
+
+	t.Run("up", func(t *testing.T) {
+		out := ResizeExact(src, 16, 16)
+		if out.Bounds() != image.Rect(0, 0, 16, 16) {
+			t.Fatalf("wrong bounds: %v", out.Bounds())
+		}
+		if c := out.RGBAAt(0, 0); c != red {
+			t.Errorf("left: %v", c)
+		}
+		if c := out.RGBAAt(15, 15); c != blue {
+			t.Errorf("right: %v", c)
+		}
+		// Bilinear interpolation should give a blend at the border.
+		if c := out.RGBAAt(8, 8); c.R == 0 || c.B == 0 {
+			t.Errorf("centre not interpolated: %v", c)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		out := ResizeExact(image.NewRGBA(image.Rect(0, 0, 0, 0)), 4, 4)
+		if out.Bounds() != image.Rect(0, 0, 4, 4) {
+			t.Fatalf("wrong bounds: %v", out.Bounds())
+		}
+	})
+}
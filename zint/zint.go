@@ -2,7 +2,10 @@
 package zint
 
 import (
+	"fmt"
 	"math"
+	"math/bits"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -16,12 +19,53 @@ func (s Int) Int() int         { return int(s) }
 func (s Int) Int64() int64     { return int64(s) }
 func (s Int) Float32() float32 { return float32(s) }
 func (s Int) Float64() float64 { return float64(s) }
+func (s Int) Bool() bool       { return s != 0 }
+
+// Abs returns the absolute value.
+func (s Int) Abs() Int {
+	if s < 0 {
+		return -s
+	}
+	return s
+}
+
+// Sign returns -1 if the value is negative, 1 if it's positive, and 0 if it's
+// zero.
+func (s Int) Sign() int {
+	switch {
+	case s < 0:
+		return -1
+	case s > 0:
+		return 1
+	}
+	return 0
+}
+
+// Clamp the value to be within lo and hi (inclusive).
+func (s Int) Clamp(lo, hi Int) Int {
+	if s < lo {
+		return lo
+	}
+	if s > hi {
+		return hi
+	}
+	return s
+}
 
 // Join a slice of ints to a comma separated string with the given separator.
 func Join(ints []int64, sep string) string {
+	return JoinBase(ints, sep, 10)
+}
+
+// JoinBase joins a slice of ints to a string with the given separator, using
+// the given base for the numbers.
+//
+// The base must be between 2 and 36, and lower-case letters are used for digit
+// values >= 10 (as with strconv.FormatInt).
+func JoinBase(ints []int64, sep string, base int) string {
 	s := make([]string, len(ints))
 	for i := range ints {
-		s[i] = strconv.FormatInt(ints[i], 10)
+		s[i] = strconv.FormatInt(ints[i], base)
 	}
 	return strings.Join(s, sep)
 }
@@ -41,6 +85,16 @@ func Uniq(list []int64) []int64 {
 
 // Split a string to a slice of []int64.
 func Split(s string, sep string) ([]int64, error) {
+	return SplitBase(s, sep, 10)
+}
+
+// SplitBase splits a string to a slice of []int64, parsing the numbers in the
+// given base.
+//
+// The base is interpreted as with strconv.ParseInt; if it's 0 then the base is
+// derived from the prefix of every number ("0x" for 16, "0o" or "0" for 8, and
+// "0b" for 2).
+func SplitBase(s string, sep string, base int) ([]int64, error) {
 	s = strings.Trim(s, " \t\n"+sep)
 	if len(s) == 0 {
 		return nil, nil
@@ -49,7 +103,7 @@ func Split(s string, sep string) ([]int64, error) {
 	items := strings.Split(s, sep)
 	ret := make([]int64, len(items))
 	for i := range items {
-		val, err := strconv.ParseInt(strings.TrimSpace(items[i]), 10, 64)
+		val, err := strconv.ParseInt(strings.TrimSpace(items[i]), base, 64)
 		if err != nil {
 			return nil, err
 		}
@@ -59,6 +113,91 @@ func Split(s string, sep string) ([]int64, error) {
 	return ret, nil
 }
 
+// MaxRange is the maximum number of values that ParseRange and ParseList
+// accept, to prevent allocating huge amounts of memory when expanding ranges
+// from user input such as "0-9999999999".
+//
+// For ParseList this limits the total for the whole list, and not just every
+// range on its own.
+var MaxRange int64 = 1 << 20
+
+// ParseRange parses a single number or range, such as "5" or "1-3".
+//
+// Negative numbers are allowed, e.g. "-5" or "-5--2", as is whitespace around
+// the numbers. It's an error if the start of the range is higher than the end
+// (e.g. "8-5"), or if the range has more than MaxRange values.
+func ParseRange(s string) (start, end int64, err error) {
+	s = strings.TrimSpace(s)
+	startS, endS := s, s
+	// Start at 1 to allow negative numbers, e.g. "-5" or "-5--2".
+	if len(s) > 0 {
+		if i := strings.Index(s[1:], "-"); i > -1 {
+			startS, endS = strings.TrimSpace(s[:i+1]), strings.TrimSpace(s[i+2:])
+		}
+	}
+
+	start, err = strconv.ParseInt(startS, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("zint.ParseRange: invalid number %q in %q: %w", startS, s, err)
+	}
+	end, err = strconv.ParseInt(endS, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("zint.ParseRange: invalid number %q in %q: %w", endS, s, err)
+	}
+	if start > end {
+		return 0, 0, fmt.Errorf("zint.ParseRange: start of range is higher than end: %q", s)
+	}
+	// Calculate as uint64 so that e.g. MinInt64-MaxInt64 doesn't overflow.
+	if uint64(end)-uint64(start) >= uint64(MaxRange) {
+		return 0, 0, fmt.Errorf("zint.ParseRange: range %q has more than %d values", s, MaxRange)
+	}
+	return start, end, nil
+}
+
+// ParseList parses a comma-separated list of numbers and ranges to a slice of
+// []int64.
+//
+// Ranges are expanded, so "1,3,5-8,10" will result in [1 3 5 6 7 8 10]. The
+// return value is sorted and duplicates are removed. Ranges are parsed with
+// ParseRange, and it's an error if the list expands to more than MaxRange
+// values.
+func ParseList(s string) ([]int64, error) {
+	items := strings.Split(strings.Trim(s, " \t\n,"), ",")
+	var (
+		ret   []int64
+		total uint64
+		seen  = make(map[int64]struct{})
+	)
+	for _, item := range items {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+
+		s, e, err := ParseRange(item)
+		if err != nil {
+			return nil, fmt.Errorf("zint.ParseList: %w", err)
+		}
+		// ParseRange already limits every range to MaxRange, so this can't
+		// overflow.
+		total += uint64(e) - uint64(s) + 1
+		if total > uint64(MaxRange) {
+			return nil, fmt.Errorf("zint.ParseList: list has more than %d values", MaxRange)
+		}
+		for n := s; ; n++ {
+			if _, ok := seen[n]; !ok {
+				seen[n] = struct{}{}
+				ret = append(ret, n)
+			}
+			if n == e {
+				break
+			}
+		}
+	}
+
+	Sort(ret)
+	return ret, nil
+}
+
 // Contains reports whether i is within the list.
 func Contains(list []int, i int) bool {
 	for _, item := range list {
@@ -105,6 +244,31 @@ func Filter(list []int64, fun func(int64) bool) []int64 {
 // e is empty or contains only whitespace.
 func FilterEmpty(e int64) bool { return e != 0 }
 
+// Chunk splits list into chunks of at most size elements; the last chunk will
+// have fewer elements if len(list) isn't a multiple of size.
+//
+// The chunks are sub-slices of list, but appending to a chunk will never
+// modify list. It returns a single chunk with all elements if size is 0 or
+// lower, and nil if the list is empty.
+func Chunk(list []int64, size int) [][]int64 {
+	if len(list) == 0 {
+		return nil
+	}
+	if size <= 0 || size > len(list) {
+		size = len(list)
+	}
+
+	chunks := make([][]int64, 0, (len(list)+size-1)/size)
+	for i := 0; i < len(list); i += size {
+		end := i + size
+		if end > len(list) {
+			end = len(list)
+		}
+		chunks = append(chunks, list[i:end:end])
+	}
+	return chunks
+}
+
 // Min gets the lowest of two numbers.
 func Min(a, b int64) int64 {
 	if a > b {
@@ -121,6 +285,36 @@ func Max(a, b int64) int64 {
 	return a
 }
 
+// MinSlice gets the lowest number in the list; ok is false if the list is
+// empty.
+func MinSlice(list []int64) (min int64, ok bool) {
+	if len(list) == 0 {
+		return 0, false
+	}
+	min = list[0]
+	for _, n := range list[1:] {
+		if n < min {
+			min = n
+		}
+	}
+	return min, true
+}
+
+// MaxSlice gets the highest number in the list; ok is false if the list is
+// empty.
+func MaxSlice(list []int64) (max int64, ok bool) {
+	if len(list) == 0 {
+		return 0, false
+	}
+	max = list[0]
+	for _, n := range list[1:] {
+		if n > max {
+			max = n
+		}
+	}
+	return max, true
+}
+
 // NonZero returns the first argument that is not 0. It will return 0 if all
 // arguments are 0.
 func NonZero(a, b int64, c ...int64) int64 {
@@ -144,23 +338,660 @@ func DivideCeil(count int64, pageSize int64) int64 {
 	return int64(math.Ceil(float64(count) / float64(pageSize)))
 }
 
-// Differencereturns a new slice with elements that are in "set" but not in
+// Difference returns a new slice with elements that are in "set" but not in
 // "others".
 func Difference(set []int64, others ...[]int64) []int64 {
+	seen := make(map[int64]struct{})
+	for _, o := range others {
+		for _, item := range o {
+			seen[item] = struct{}{}
+		}
+	}
+
 	out := []int64{}
 	for _, setItem := range set {
-		found := false
-		for _, o := range others {
-			if Contains64(o, setItem) {
-				found = true
+		if _, ok := seen[setItem]; !ok {
+			out = append(out, setItem)
+		}
+	}
+
+	return out
+}
+
+// Intersection returns a new slice with elements that are in "set" and in all
+// of "others".
+//
+// The order of "set" is preserved, as are any duplicates in it.
+func Intersection(set []int64, others ...[]int64) []int64 {
+	seen := make([]map[int64]struct{}, len(others))
+	for i, o := range others {
+		seen[i] = make(map[int64]struct{}, len(o))
+		for _, item := range o {
+			seen[i][item] = struct{}{}
+		}
+	}
+
+	out := []int64{}
+	for _, setItem := range set {
+		found := true
+		for _, s := range seen {
+			if _, ok := s[setItem]; !ok {
+				found = false
 				break
 			}
 		}
 
-		if !found {
+		if found {
 			out = append(out, setItem)
 		}
 	}
 
 	return out
 }
+
+// Union returns a new slice with all unique elements that are in "set" or in
+// any of "others".
+//
+// Elements are added in the order they're first seen, starting with "set".
+func Union(set []int64, others ...[]int64) []int64 {
+	out := []int64{}
+	seen := make(map[int64]struct{})
+	for _, list := range append([][]int64{set}, others...) {
+		for _, item := range list {
+			if _, ok := seen[item]; !ok {
+				seen[item] = struct{}{}
+				out = append(out, item)
+			}
+		}
+	}
+
+	return out
+}
+
+// Pow returns base raised to the power of exp.
+//
+// exp of 0 always returns 1. A negative exp returns 0, except for a base of 1
+// or -1 (as the result would be a fraction otherwise).
+//
+// The result will silently overflow for large values, just like regular
+// multiplication; use MulOverflow if you need to detect this.
+func Pow(base, exp int64) int64 {
+	if exp < 0 {
+		switch {
+		case base == 1:
+			return 1
+		case base == -1 && exp%2 == 0:
+			return 1
+		case base == -1:
+			return -1
+		}
+		return 0
+	}
+
+	r := int64(1)
+	for exp > 0 {
+		if exp&1 == 1 {
+			r *= base
+		}
+		base *= base
+		exp >>= 1
+	}
+	return r
+}
+
+// AddOverflow returns a + b, and reports if the result overflowed.
+func AddOverflow(a, b int64) (int64, bool) {
+	c := a + b
+	return c, (a^c)&(b^c) < 0
+}
+
+// MulOverflow returns a * b, and reports if the result overflowed.
+func MulOverflow(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, false
+	}
+	c := a * b
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return c, true
+	}
+	return c, c/b != a
+}
+
+// Factorial returns n!.
+//
+// ok is false if the result overflows an int64 (n > 20) or if n is negative.
+func Factorial(n int64) (r int64, ok bool) {
+	if n < 0 || n > 20 {
+		return 0, false
+	}
+	r = 1
+	for i := int64(2); i <= n; i++ {
+		r *= i
+	}
+	return r, true
+}
+
+// Binomial returns the binomial coefficient "n choose k".
+//
+// This doesn't compute the factorials, so it works for larger values than
+// Factorial does; ok is false if the result overflows an int64. It returns 0
+// if k is negative or larger than n.
+func Binomial(n, k int64) (r int64, ok bool) {
+	if k < 0 || n < 0 || k > n {
+		return 0, true
+	}
+	if k > n-k {
+		k = n - k
+	}
+
+	// r*(n-k+i) is always divisible by i; divide out the common factor first
+	// so the intermediate value doesn't overflow when the result would fit.
+	r = 1
+	for i := int64(1); i <= k; i++ {
+		g := GCD(r, i)
+		var overflow bool
+		r, overflow = MulOverflow(r/g, (n-k+i)/(i/g))
+		if overflow {
+			return 0, false
+		}
+	}
+	return r, true
+}
+
+// GCD returns the greatest common divisor of a and b.
+//
+// The result is always positive, except that GCD(0, 0) is 0. GCD(n, 0) is the
+// absolute value of n. The result overflows if it's 2⁶³, which can only happen
+// if both inputs are math.MinInt64 or 0.
+func GCD(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// GCDSlice returns the greatest common divisor of all numbers in the list, or 0
+// if the list is empty.
+func GCDSlice(list []int64) int64 {
+	var g int64
+	for _, n := range list {
+		g = GCD(g, n)
+		if g == 1 {
+			break
+		}
+	}
+	return g
+}
+
+// LCM returns the least common multiple of a and b; ok is false if the result
+// overflows an int64.
+//
+// The result is always positive, except that the LCM is 0 if either a or b is
+// 0.
+func LCM(a, b int64) (r int64, ok bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	r, overflow := MulOverflow(a/GCD(a, b), b)
+	if overflow || r == math.MinInt64 {
+		return 0, false
+	}
+	if r < 0 {
+		r = -r
+	}
+	return r, true
+}
+
+// LCMSlice returns the least common multiple of all numbers in the list, or 1
+// if the list is empty; ok is false if the result overflows an int64.
+func LCMSlice(list []int64) (r int64, ok bool) {
+	r = 1
+	for _, n := range list {
+		r, ok = LCM(r, n)
+		if !ok {
+			return 0, false
+		}
+	}
+	return r, true
+}
+
+// Sort the list in ascending order, modifying it in place.
+func Sort(list []int64) {
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+}
+
+// SortDesc sorts the list in descending order, modifying it in place.
+func SortDesc(list []int64) {
+	sort.Slice(list, func(i, j int) bool { return list[i] > list[j] })
+}
+
+// Sorted returns a sorted copy of list in ascending order; the original list is
+// not modified.
+func Sorted(list []int64) []int64 {
+	if list == nil {
+		return nil
+	}
+	c := make([]int64, len(list))
+	copy(c, list)
+	Sort(c)
+	return c
+}
+
+// SortedDesc returns a sorted copy of list in descending order; the original
+// list is not modified.
+func SortedDesc(list []int64) []int64 {
+	if list == nil {
+		return nil
+	}
+	c := make([]int64, len(list))
+	copy(c, list)
+	SortDesc(c)
+	return c
+}
+
+// FormatClock formats the number of seconds as a clock time, such as "1:02:03".
+//
+// The hours are omitted if they're 0 (e.g. "2:03" or "0:05"). Negative values
+// are prefixed with a "-".
+func FormatClock(seconds int64) string {
+	sign, h, m, s := clock(seconds)
+	if h == 0 {
+		return fmt.Sprintf("%s%d:%02d", sign, m, s)
+	}
+	return fmt.Sprintf("%s%d:%02d:%02d", sign, h, m, s)
+}
+
+// FormatClockFull formats the number of seconds as a clock time in the form of
+// "HH:MM:SS", such as "01:02:03" or "00:00:05".
+//
+// Negative values are prefixed with a "-".
+func FormatClockFull(seconds int64) string {
+	sign, h, m, s := clock(seconds)
+	return fmt.Sprintf("%s%02d:%02d:%02d", sign, h, m, s)
+}
+
+func clock(seconds int64) (string, uint64, uint64, uint64) {
+	var sign string
+	n := uint64(seconds)
+	if seconds < 0 {
+		sign, n = "-", uint64(-seconds)
+	}
+	return sign, n / 3600, n % 3600 / 60, n % 60
+}
+
+// ProgressBar returns a progress bar such as "[####----] 50%", where width is
+// the number of characters between the brackets.
+//
+// The current value is clamped to 0 and total. A total of 0 or lower results in
+// an empty bar.
+func ProgressBar(current, total int64, width int) string {
+	if width < 0 {
+		width = 0
+	}
+	var perc, fill uint64
+	if total > 0 {
+		// Calculate c*x/total with integers: floats are inexact and would
+		// round e.g. 29/100 down to 28%.
+		c := uint64(Max(0, Min(current, total)))
+		scale := func(x uint64) uint64 {
+			hi, lo := bits.Mul64(c, x)
+			q, _ := bits.Div64(hi, lo, uint64(total))
+			return q
+		}
+		perc, fill = scale(100), scale(uint64(width))
+	}
+
+	return fmt.Sprintf("[%s%s] %d%%",
+		strings.Repeat("#", int(fill)), strings.Repeat("-", width-int(fill)), perc)
+}
+
+// WeightedRoundRobin returns a sequence of n indexes into weights, where every
+// index appears in proportion to its weight.
+//
+// This uses the "smooth weighted round-robin" algorithm from nginx, so the
+// indexes are interleaved rather than clustered: weights of {5, 1, 1} give
+// [0 0 1 0 2 0 0] rather than [0 0 0 0 0 1 2]. Over a full cycle (the sum of
+// all weights) every index appears exactly weight times.
+//
+// Weights of 0 or lower are never selected; it returns nil if there are no
+// positive weights, or if the sum of the weights overflows int64.
+func WeightedRoundRobin(weights []int64, n int) []int {
+	var (
+		total int64
+		ovf   bool
+	)
+	for _, w := range weights {
+		if w > 0 {
+			total, ovf = AddOverflow(total, w)
+			if ovf {
+				return nil
+			}
+		}
+	}
+	if total == 0 || n <= 0 {
+		return nil
+	}
+
+	var (
+		seq     = make([]int, n)
+		current = make([]int64, len(weights))
+	)
+	for i := range seq {
+		best := -1
+		for j, w := range weights {
+			if w <= 0 {
+				continue
+			}
+			current[j] += w
+			if best == -1 || current[j] > current[best] {
+				best = j
+			}
+		}
+		current[best] -= total
+		seq[i] = best
+	}
+	return seq
+}
+
+// FormatBase formats n in the given base, inserting sep every group digits
+// (counting from the right).
+//
+// For example FormatBase(0xdeadbeef, 16, 4, " ") returns "dead beef". No
+// separators are added if group is 0 or lower. The base must be between 2 and
+// 36, as with strconv.FormatInt().
+func FormatBase(n int64, base int, group int, sep string) string {
+	s := strconv.FormatInt(n, base)
+	if group <= 0 {
+		return s
+	}
+
+	var sign string
+	if s[0] == '-' {
+		sign, s = "-", s[1:]
+	}
+
+	var b strings.Builder
+	b.WriteString(sign)
+	first := len(s) % group
+	if first == 0 {
+		first = group
+	}
+	b.WriteString(s[:first])
+	for i := first; i < len(s); i += group {
+		b.WriteString(sep)
+		b.WriteString(s[i : i+group])
+	}
+	return b.String()
+}
+
+var humanUnits = []string{"", "k", "M", "G", "T", "P", "E"}
+
+// ParseHuman parses a human-readable number with an optional unit suffix, such
+// as "1k", "2M", or "1.5G".
+//
+// The units k, M, G, T, P, and E are multiples of 1000 (SI), or of 1024 if
+// binary is true. The suffix is case-insensitive, and a unit followed by "i"
+// (e.g. "Ki", "Mi") is always a multiple of 1024.
+//
+// The result is rounded to the nearest integer if the number has a fraction.
+func ParseHuman(s string, binary bool) (int64, error) {
+	num := strings.TrimSpace(s)
+
+	var mult float64 = 1
+	if l := len(num); l > 0 {
+		if num[l-1] == 'i' || num[l-1] == 'I' {
+			binary = true
+			num = num[:l-1]
+			l--
+			if l == 0 || strings.IndexByte("kKmMgGtTpPeE", num[l-1]) == -1 {
+				return 0, fmt.Errorf("zint.ParseHuman: invalid unit in %q", s)
+			}
+		}
+		base := 1000.0
+		if binary {
+			base = 1024
+		}
+		for i, u := range humanUnits[1:] {
+			if l > 0 && strings.EqualFold(num[l-1:], u) {
+				mult = math.Pow(base, float64(i+1))
+				num = strings.TrimSpace(num[:l-1])
+				break
+			}
+		}
+	}
+	if num == "" {
+		return 0, fmt.Errorf("zint.ParseHuman: no number in %q", s)
+	}
+
+	// Try to parse as an integer first, so there's no loss of precision.
+	if n, err := strconv.ParseInt(num, 10, 64); err == nil {
+		r, overflow := MulOverflow(n, int64(mult))
+		if overflow {
+			return 0, fmt.Errorf("zint.ParseHuman: %q is out of range", s)
+		}
+		return r, nil
+	}
+
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("zint.ParseHuman: invalid number %q", s)
+	}
+	f = math.Round(f * mult)
+	if f >= math.MaxInt64 || f < math.MinInt64 {
+		return 0, fmt.Errorf("zint.ParseHuman: %q is out of range", s)
+	}
+	return int64(f), nil
+}
+
+// FormatHuman formats n as a human-readable number with a unit suffix, such as
+// "1k", "2M", or "1.5G".
+//
+// The units are multiples of 1000 (SI), or of 1024 if binary is true, in which
+// case the unit has an "i" appended (e.g. "1.5Ki"). There is at most one
+// decimal, and numbers lower than 1000 (or 1024) are returned as-is.
+//
+// ParseHuman can parse the output back, but it may have lost precision.
+func FormatHuman(n int64, binary bool) string {
+	base, suffix := 1000.0, ""
+	if binary {
+		base, suffix = 1024, "i"
+	}
+
+	f := math.Abs(float64(n))
+	if f < base {
+		return strconv.FormatInt(n, 10)
+	}
+
+	i := 0
+	for i < len(humanUnits)-1 && math.Round(f*10)/10 >= base {
+		f /= base
+		i++
+	}
+	f = math.Round(f*10) / 10
+	if n < 0 {
+		f = -f
+	}
+	u := humanUnits[i]
+	if binary && u == "k" {
+		u = "K" // IEC uses "Ki", not "ki".
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64) + u + suffix
+}
+
+// IsPrime reports if n is a prime number.
+//
+// This uses a deterministic variant of the Miller-Rabin test, which is correct
+// for all int64 values.
+func IsPrime(n int64) bool {
+	if n < 2 {
+		return false
+	}
+	for _, p := range []int64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37} {
+		if n == p {
+			return true
+		}
+		if n%p == 0 {
+			return false
+		}
+	}
+
+	// Write n-1 as d·2ˢ.
+	var (
+		m = uint64(n)
+		d = m - 1
+		s = bits.TrailingZeros64(d)
+	)
+	d >>= uint(s)
+
+	// These bases are enough to give a correct answer for all n < 2⁶⁴.
+outer:
+	for _, a := range []uint64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37} {
+		x := powMod(a, d, m)
+		if x == 1 || x == m-1 {
+			continue
+		}
+		for i := 1; i < s; i++ {
+			x = mulMod(x, x, m)
+			if x == m-1 {
+				continue outer
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// NextPrime returns the smallest prime number greater than n, or 0 if there is
+// no such number that fits in an int64.
+func NextPrime(n int64) int64 {
+	if n < 2 {
+		return 2
+	}
+	// Check only odd numbers.
+	p := n + 1
+	if p%2 == 0 {
+		p++
+	}
+	for ; p > 0; p += 2 {
+		if IsPrime(p) {
+			return p
+		}
+	}
+	return 0
+}
+
+func mulMod(a, b, m uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	_, r := bits.Div64(hi, lo, m)
+	return r
+}
+
+func powMod(b, e, m uint64) uint64 {
+	r := uint64(1)
+	b %= m
+	for e > 0 {
+		if e&1 == 1 {
+			r = mulMod(r, b, m)
+		}
+		b = mulMod(b, b, m)
+		e >>= 1
+	}
+	return r
+}
+
+// ToBytes encodes n as a compact big-endian byte slice.
+//
+// The number is zigzag-encoded so that small negative numbers are also short,
+// and leading zero bytes are removed: values from -64 to 63 take 1 byte, and
+// the largest values take 8 bytes. Zero is encoded as a single zero byte.
+//
+// Use FromBytes to decode the value.
+func ToBytes(n int64) []byte {
+	z := uint64(n<<1) ^ uint64(n>>63)
+	l := (bits.Len64(z) + 7) / 8
+	if l == 0 {
+		l = 1
+	}
+	b := make([]byte, l)
+	for i := l - 1; i >= 0; i-- {
+		b[i] = byte(z)
+		z >>= 8
+	}
+	return b
+}
+
+// FromBytes decodes a number encoded with ToBytes.
+func FromBytes(b []byte) (int64, error) {
+	if len(b) == 0 || len(b) > 8 {
+		return 0, fmt.Errorf("zint.FromBytes: invalid length %d; must be between 1 and 8", len(b))
+	}
+	var z uint64
+	for _, c := range b {
+		z = z<<8 | uint64(c)
+	}
+	return int64(z>>1) ^ -int64(z&1), nil
+}
+
+// Histogram divides the range from the lowest to the highest value in to
+// evenly sized buckets, and counts the number of values in every bucket.
+//
+// It returns the lower bound of every bucket, and the count for every bucket.
+// Bucket i contains the values from bounds[i] up to (but not including)
+// bounds[i+1]; the last bucket contains all values up to the highest value.
+//
+// Fewer buckets are returned if there are fewer distinct possible values in the
+// range than buckets; for example if all values are equal there is just one
+// bucket. It returns nil if values is empty or buckets is 0 or lower.
+func Histogram(values []int64, buckets int) (bounds, counts []int64) {
+	min, ok := MinSlice(values)
+	if !ok || buckets <= 0 {
+		return nil, nil
+	}
+	max, _ := MaxSlice(values)
+
+	// The number of possible values in the range; 0 means 2⁶⁴ (the full
+	// int64 range), which doesn't fit.
+	span := uint64(max-min) + 1
+	n := uint64(buckets)
+	if span != 0 && span < n {
+		n = span
+	}
+
+	// Calculate x*y/span without overflowing.
+	scale := func(x, y uint64) uint64 {
+		hi, lo := bits.Mul64(x, y)
+		if span == 0 {
+			return hi
+		}
+		q, _ := bits.Div64(hi, lo, span)
+		return q
+	}
+	// The lower bound is the smallest value for which scale() returns i;
+	// this is span*i/n, rounded up.
+	lower := func(i uint64) uint64 {
+		var q, r uint64
+		if span == 0 {
+			q, r = bits.Div64(i, 0, n)
+		} else {
+			hi, lo := bits.Mul64(span, i)
+			q, r = bits.Div64(hi, lo, n)
+		}
+		if r != 0 {
+			q++
+		}
+		return q
+	}
+
+	bounds = make([]int64, n)
+	counts = make([]int64, n)
+	for i := range bounds {
+		bounds[i] = min + int64(lower(uint64(i)))
+	}
+	for _, v := range values {
+		counts[scale(uint64(v-min), n)]++
+	}
+	return bounds, counts
+}
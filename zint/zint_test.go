@@ -3,8 +3,13 @@ package zint
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"math/rand"
 	"reflect"
+	"strings"
 	"testing"
+
+	"zgo.at/zstd/ztest"
 )
 
 func TestNonZero(t *testing.T) {
@@ -104,6 +109,69 @@ func TestJoin(t *testing.T) {
 	}
 }
 
+func TestJoinBase(t *testing.T) {
+	tests := []struct {
+		in   []int64
+		base int
+		want string
+	}{
+		{nil, 16, ""},
+		{[]int64{255, -255, 0}, 16, "ff,-ff,0"},
+		{[]int64{5, -5}, 2, "101,-101"},
+		{[]int64{8, 64}, 8, "10,100"},
+		{[]int64{35}, 36, "z"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			out := JoinBase(tt.in, ",", tt.base)
+			if out != tt.want {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+
+			back, err := SplitBase(out, ",", tt.base)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tt.in) > 0 && !reflect.DeepEqual(back, tt.in) {
+				t.Errorf("round-trip\nout:  %#v\nwant: %#v\n", back, tt.in)
+			}
+		})
+	}
+}
+
+func TestSplitBase(t *testing.T) {
+	tests := []struct {
+		in      string
+		base    int
+		want    []int64
+		wantErr string
+	}{
+		{"", 10, nil, ""},
+		{"1, 2,-3", 10, []int64{1, 2, -3}, ""},
+		{"ff,-FF,10", 16, []int64{255, -255, 16}, ""},
+		{"101,-1", 2, []int64{5, -1}, ""},
+		{"0xff,-0x10,0b11,0o17,017,42", 0, []int64{255, -16, 3, 15, 15, 42}, ""},
+
+		{"0xff", 16, nil, "invalid syntax"},
+		{"12", 2, nil, "invalid syntax"},
+		{"ff", 10, nil, "invalid syntax"},
+		{"1", 1, nil, "invalid base"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			out, err := SplitBase(tt.in, ",", tt.base)
+			if !ztest.ErrorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nout:  %v\nwant: %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(tt.want, out) {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+		})
+	}
+}
+
 func TestUniq(t *testing.T) {
 	cases := []struct {
 		in       []int64
@@ -141,6 +209,75 @@ func TestUniq(t *testing.T) {
 	}
 }
 
+func TestParseList(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []int64
+		wantErr string
+	}{
+		{"", nil, ""},
+		{"1", []int64{1}, ""},
+		{"1,3,5-8,10", []int64{1, 3, 5, 6, 7, 8, 10}, ""},
+		{"10,1,3-5,4", []int64{1, 3, 4, 5, 10}, ""},
+		{" 1 , 3 - 5,\t7 ,", []int64{1, 3, 4, 5, 7}, ""},
+		{"-3--1,2", []int64{-3, -2, -1, 2}, ""},
+		{"5-5", []int64{5}, ""},
+
+		{"8-5", nil, "start of range is higher than end"},
+		{"1,x", nil, "invalid syntax"},
+		{"1-x", nil, "invalid syntax"},
+		{"0-9999999999", nil, "has more than 1048576 values"},
+		{"0-524287,524288-1048575,1048576", nil, "list has more than 1048576 values"},
+		{strings.Repeat("0-999999,", 100), nil, "list has more than 1048576 values"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			out, err := ParseList(tt.in)
+			if !ztest.ErrorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nout:  %v\nwant: %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(tt.want, out) {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		in         string
+		start, end int64
+		wantErr    string
+	}{
+		{"5", 5, 5, ""},
+		{" 1 - 3 ", 1, 3, ""},
+		{"-5--2", -5, -2, ""},
+		{"-2-1", -2, 1, ""},
+		{"0-1048575", 0, 1048575, ""},
+
+		{"", 0, 0, `invalid number ""`},
+		{"1-", 0, 0, `invalid number ""`},
+		{"8-5", 0, 0, "start of range is higher than end"},
+		{"1-2-3", 0, 0, `invalid number "2-3"`},
+		{"0-1048576", 0, 0, "has more than 1048576 values"},
+		{"0-9999999999", 0, 0, "has more than 1048576 values"},
+		{"-9223372036854775808-9223372036854775807", 0, 0, "has more than 1048576 values"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			start, end, err := ParseRange(tt.in)
+			if !ztest.ErrorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nout:  %v\nwant: %v", err, tt.wantErr)
+			}
+			if start != tt.start || end != tt.end {
+				t.Errorf("\nout:  %d, %d\nwant: %d, %d\n", start, end, tt.start, tt.end)
+			}
+		})
+	}
+}
+
 func TestContains(t *testing.T) {
 	tests := []struct {
 		list     []int
@@ -237,6 +374,119 @@ func TestDifference(t *testing.T) {
 	}
 }
 
+// differenceSlow is the old O(n*m) implementation of Difference.
+func differenceSlow(set []int64, others ...[]int64) []int64 {
+	out := []int64{}
+	for _, setItem := range set {
+		found := false
+		for _, o := range others {
+			if Contains64(o, setItem) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			out = append(out, setItem)
+		}
+	}
+	return out
+}
+
+func TestDifferenceRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	list := func() []int64 {
+		l := make([]int64, r.Intn(50))
+		for i := range l {
+			l[i] = r.Int63n(100) - 50
+		}
+		return l
+	}
+
+	for i := 0; i < 500; i++ {
+		set := list()
+		others := make([][]int64, r.Intn(4))
+		for j := range others {
+			others[j] = list()
+		}
+
+		out := Difference(set, others...)
+		want := differenceSlow(set, others...)
+		if !reflect.DeepEqual(out, want) {
+			t.Fatalf("\nset:    %v\nothers: %v\nout:    %v\nwant:   %v", set, others, out, want)
+		}
+	}
+}
+
+func BenchmarkDifference(b *testing.B) {
+	set := make([]int64, 1000)
+	other := make([]int64, 1000)
+	for i := range set {
+		set[i] = int64(i)
+		other[i] = int64(i * 2)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		Difference(set, other)
+	}
+}
+
+func TestIntersection(t *testing.T) {
+	tests := []struct {
+		inSet    []int64
+		inOthers [][]int64
+		want     []int64
+	}{
+		{[]int64{}, [][]int64{}, []int64{}},
+		{nil, nil, []int64{}},
+		{[]int64{1, 2}, nil, []int64{1, 2}},
+		{[]int64{1, 2}, [][]int64{{}}, []int64{}},
+		{[]int64{1, 2}, [][]int64{{3, 4}}, []int64{}},
+		{[]int64{1, 2, 3}, [][]int64{{3, 2}}, []int64{2, 3}},
+		{[]int64{3, 1, 2}, [][]int64{{1, 2, 3}}, []int64{3, 1, 2}},
+		{[]int64{1, 2, 2, 3}, [][]int64{{2, 3}}, []int64{2, 2, 3}},
+		{[]int64{1, 2, 3}, [][]int64{{1, 2}, {2, 3}}, []int64{2}},
+		{[]int64{1, 2, 3}, [][]int64{{1, 2}, {}}, []int64{}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			out := Intersection(tt.inSet, tt.inOthers...)
+			if !reflect.DeepEqual(tt.want, out) {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnion(t *testing.T) {
+	tests := []struct {
+		inSet    []int64
+		inOthers [][]int64
+		want     []int64
+	}{
+		{[]int64{}, [][]int64{}, []int64{}},
+		{nil, nil, []int64{}},
+		{nil, [][]int64{{1}}, []int64{1}},
+		{[]int64{1, 2}, [][]int64{{}}, []int64{1, 2}},
+		{[]int64{1, 2}, [][]int64{{3, 4}}, []int64{1, 2, 3, 4}},
+		{[]int64{3, 1, 2}, [][]int64{{4, 2, 1}}, []int64{3, 1, 2, 4}},
+		{[]int64{1, 1, 2}, [][]int64{{2, 2}}, []int64{1, 2}},
+		{[]int64{1}, [][]int64{{2}, {3, 1}}, []int64{1, 2, 3}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			out := Union(tt.inSet, tt.inOthers...)
+			if !reflect.DeepEqual(tt.want, out) {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+		})
+	}
+}
+
 func TestInt(t *testing.T) {
 	i := Int(42)
 
@@ -265,6 +515,41 @@ func TestInt(t *testing.T) {
 	}
 }
 
+func TestIntMethods(t *testing.T) {
+	tests := []struct {
+		in    Int
+		abs   Int
+		sign  int
+		clamp Int
+		b     bool
+	}{
+		{0, 0, 0, 0, false},
+		{1, 1, 1, 1, true},
+		{-1, 1, -1, -1, true},
+		{42, 42, 1, 10, true},
+		{-42, 42, -1, -10, true},
+		{10, 10, 1, 10, true},
+		{-10, 10, -1, -10, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in.String(), func(t *testing.T) {
+			if v := tt.in.Abs(); v != tt.abs {
+				t.Errorf("Abs: %#v; want %#v", v, tt.abs)
+			}
+			if v := tt.in.Sign(); v != tt.sign {
+				t.Errorf("Sign: %#v; want %#v", v, tt.sign)
+			}
+			if v := tt.in.Clamp(-10, 10); v != tt.clamp {
+				t.Errorf("Clamp: %#v; want %#v", v, tt.clamp)
+			}
+			if v := tt.in.Bool(); v != tt.b {
+				t.Errorf("Bool: %#v; want %#v", v, tt.b)
+			}
+		})
+	}
+}
+
 func BenchmarkJoin(b *testing.B) {
 	b.ReportAllocs()
 	l := []int64{213, 52, 6342, 123, 6, 873, 123, 5463, 767, 12312, 1211, 90}
@@ -272,3 +557,833 @@ func BenchmarkJoin(b *testing.B) {
 		Join(l, "")
 	}
 }
+
+func TestPow(t *testing.T) {
+	tests := []struct {
+		base, exp, want int64
+	}{
+		{0, 0, 1},
+		{5, 0, 1},
+		{-5, 0, 1},
+		{0, 3, 0},
+		{1, 100, 1},
+		{2, 1, 2},
+		{2, 10, 1024},
+		{3, 4, 81},
+		{-2, 3, -8},
+		{-2, 4, 16},
+		{10, 18, 1_000_000_000_000_000_000},
+		{2, 62, 1 << 62},
+
+		{2, -1, 0},
+		{0, -1, 0},
+		{1, -5, 1},
+		{-1, -2, 1},
+		{-1, -3, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v^%v", tt.base, tt.exp), func(t *testing.T) {
+			out := Pow(tt.base, tt.exp)
+			if out != tt.want {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddOverflow(t *testing.T) {
+	tests := []struct {
+		a, b     int64
+		want     int64
+		overflow bool
+	}{
+		{0, 0, 0, false},
+		{1, 2, 3, false},
+		{-1, -2, -3, false},
+		{math.MaxInt64, 0, math.MaxInt64, false},
+		{math.MaxInt64, -1, math.MaxInt64 - 1, false},
+		{math.MinInt64, 1, math.MinInt64 + 1, false},
+		{math.MaxInt64, math.MinInt64, -1, false},
+
+		{math.MaxInt64, 1, math.MinInt64, true},
+		{math.MinInt64, -1, math.MaxInt64, true},
+		{math.MaxInt64, math.MaxInt64, -2, true},
+		{math.MinInt64, math.MinInt64, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v+%v", tt.a, tt.b), func(t *testing.T) {
+			out, overflow := AddOverflow(tt.a, tt.b)
+			if out != tt.want || overflow != tt.overflow {
+				t.Errorf("\nout:  %#v, %t\nwant: %#v, %t\n", out, overflow, tt.want, tt.overflow)
+			}
+		})
+	}
+}
+
+func TestMulOverflow(t *testing.T) {
+	tests := []struct {
+		a, b     int64
+		want     int64
+		overflow bool
+	}{
+		{0, 0, 0, false},
+		{0, math.MinInt64, 0, false},
+		{2, 3, 6, false},
+		{-2, 3, -6, false},
+		{-2, -3, 6, false},
+		{math.MaxInt64, 1, math.MaxInt64, false},
+		{math.MaxInt64, -1, -math.MaxInt64, false},
+		{math.MinInt64, 1, math.MinInt64, false},
+		{1 << 31, 1 << 31, 1 << 62, false},
+		{-1 << 31, 1 << 32, math.MinInt64, false},
+
+		{math.MaxInt64, 2, -2, true},
+		{1 << 32, 1 << 32, 0, true},
+		{math.MinInt64, -1, math.MinInt64, true},
+		{-1, math.MinInt64, math.MinInt64, true},
+		{math.MinInt64, 2, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v*%v", tt.a, tt.b), func(t *testing.T) {
+			out, overflow := MulOverflow(tt.a, tt.b)
+			if out != tt.want || overflow != tt.overflow {
+				t.Errorf("\nout:  %#v, %t\nwant: %#v, %t\n", out, overflow, tt.want, tt.overflow)
+			}
+		})
+	}
+}
+
+func TestSort(t *testing.T) {
+	tests := []struct {
+		in, want, wantDesc []int64
+	}{
+		{nil, nil, nil},
+		{[]int64{}, []int64{}, []int64{}},
+		{[]int64{1}, []int64{1}, []int64{1}},
+		{[]int64{3, -1, 2, 2, 0}, []int64{-1, 0, 2, 2, 3}, []int64{3, 2, 2, 0, -1}},
+		{[]int64{math.MaxInt64, math.MinInt64}, []int64{math.MinInt64, math.MaxInt64}, []int64{math.MaxInt64, math.MinInt64}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			orig := fmt.Sprint(tt.in)
+
+			out := Sorted(tt.in)
+			if !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("Sorted\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+			outDesc := SortedDesc(tt.in)
+			if !reflect.DeepEqual(outDesc, tt.wantDesc) {
+				t.Errorf("SortedDesc\nout:  %#v\nwant: %#v\n", outDesc, tt.wantDesc)
+			}
+			if fmt.Sprint(tt.in) != orig {
+				t.Errorf("Sorted modified the input: %v", tt.in)
+			}
+
+			SortDesc(tt.in)
+			if !reflect.DeepEqual(tt.in, tt.wantDesc) {
+				t.Errorf("SortDesc\nout:  %#v\nwant: %#v\n", tt.in, tt.wantDesc)
+			}
+			Sort(tt.in)
+			if !reflect.DeepEqual(tt.in, tt.want) {
+				t.Errorf("Sort\nout:  %#v\nwant: %#v\n", tt.in, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatClock(t *testing.T) {
+	tests := []struct {
+		in         int64
+		want, full string
+	}{
+		{0, "0:00", "00:00:00"},
+		{5, "0:05", "00:00:05"},
+		{59, "0:59", "00:00:59"},
+		{60, "1:00", "00:01:00"},
+		{123, "2:03", "00:02:03"},
+		{3599, "59:59", "00:59:59"},
+		{3600, "1:00:00", "01:00:00"},
+		{3723, "1:02:03", "01:02:03"},
+		{100 * 3600, "100:00:00", "100:00:00"},
+		{-5, "-0:05", "-00:00:05"},
+		{-3723, "-1:02:03", "-01:02:03"},
+		{math.MinInt64, "-2562047788015215:30:08", "-2562047788015215:30:08"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v", tt.in), func(t *testing.T) {
+			out := FormatClock(tt.in)
+			if out != tt.want {
+				t.Errorf("FormatClock\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+			full := FormatClockFull(tt.in)
+			if full != tt.full {
+				t.Errorf("FormatClockFull\nout:  %#v\nwant: %#v\n", full, tt.full)
+			}
+		})
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	tests := []struct {
+		current, total int64
+		width          int
+		want           string
+	}{
+		{0, 100, 8, "[--------] 0%"},
+		{50, 100, 8, "[####----] 50%"},
+		{100, 100, 8, "[########] 100%"},
+		{1, 3, 10, "[###-------] 33%"},
+		{2, 3, 10, "[######----] 66%"},
+		{150, 100, 4, "[####] 100%"},
+		{-5, 100, 4, "[----] 0%"},
+		{0, 0, 4, "[----] 0%"},
+		{5, 0, 4, "[----] 0%"},
+		{5, -1, 4, "[----] 0%"},
+		{50, 100, 0, "[] 50%"},
+		{math.MaxInt64 / 2, math.MaxInt64, 2, "[--] 49%"},
+		{math.MaxInt64/2 + 1, math.MaxInt64, 2, "[#-] 50%"},
+		{math.MaxInt64 - 1, math.MaxInt64, 100, "[" + strings.Repeat("#", 99) + "-] 99%"},
+		{math.MaxInt64, math.MaxInt64, 4, "[####] 100%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v/%v", tt.current, tt.total), func(t *testing.T) {
+			out := ProgressBar(tt.current, tt.total, tt.width)
+			if out != tt.want {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+		})
+	}
+
+	// Make sure there are no rounding errors.
+	for c := int64(0); c <= 100; c++ {
+		want := fmt.Sprintf("[%s%s] %d%%", strings.Repeat("#", int(c)), strings.Repeat("-", 100-int(c)), c)
+		if out := ProgressBar(c, 100, 100); out != want {
+			t.Errorf("\nout:  %#v\nwant: %#v\n", out, want)
+		}
+	}
+}
+
+func TestWeightedRoundRobin(t *testing.T) {
+	tests := []struct {
+		weights []int64
+		n       int
+		want    []int
+	}{
+		{nil, 5, nil},
+		{[]int64{0, -1}, 5, nil},
+		{[]int64{1}, 0, nil},
+		{[]int64{1}, 3, []int{0, 0, 0}},
+		{[]int64{1, 1}, 4, []int{0, 1, 0, 1}},
+		{[]int64{5, 1, 1}, 7, []int{0, 0, 1, 0, 2, 0, 0}},
+		{[]int64{0, 2, 1}, 6, []int{1, 2, 1, 1, 2, 1}},
+		{[]int64{2, 3}, 5, []int{1, 0, 1, 0, 1}},
+		{[]int64{math.MaxInt64 / 2, math.MaxInt64 / 2}, 3, []int{0, 1, 0}},
+		{[]int64{math.MaxInt64, 0}, 2, []int{0, 0}},
+		{[]int64{math.MaxInt64, math.MaxInt64}, 3, nil},
+		{[]int64{math.MaxInt64, 1}, 3, nil},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			out := WeightedRoundRobin(tt.weights, tt.n)
+			if !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+		})
+	}
+
+	t.Run("counts", func(t *testing.T) {
+		weights := []int64{7, 3, 0, 12, 1}
+		out := WeightedRoundRobin(weights, 23*3)
+		counts := make([]int64, len(weights))
+		for _, o := range out {
+			counts[o]++
+		}
+		for i, w := range weights {
+			if counts[i] != w*3 {
+				t.Errorf("index %d: count %d; want %d", i, counts[i], w*3)
+			}
+		}
+
+		// The heaviest index should never be picked more than twice in a row
+		// with these weights.
+		for i := 2; i < len(out); i++ {
+			if out[i] == out[i-1] && out[i] == out[i-2] {
+				t.Errorf("not interleaved at %d: %v", i, out)
+				break
+			}
+		}
+	})
+}
+
+func TestFormatBase(t *testing.T) {
+	tests := []struct {
+		n     int64
+		base  int
+		group int
+		sep   string
+		want  string
+	}{
+		{0, 10, 3, ",", "0"},
+		{0xdeadbeef, 16, 0, " ", "deadbeef"},
+		{0xdeadbeef, 16, -1, " ", "deadbeef"},
+		{0xdeadbeef, 16, 4, " ", "dead beef"},
+		{0xcafe, 16, 4, " ", "cafe"},
+		{0x1cafe, 16, 4, " ", "1 cafe"},
+		{-0xdeadbeef, 16, 4, "_", "-dead_beef"},
+		{0xff, 2, 8, " ", "11111111"},
+		{0x1ff, 2, 8, " ", "1 11111111"},
+		{0xa5a5, 2, 4, ".", "1010.0101.1010.0101"},
+		{1234567, 10, 3, ",", "1,234,567"},
+		{-123456, 10, 3, ",", "-123,456"},
+		{math.MinInt64, 16, 4, " ", "-8000 0000 0000 0000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			out := FormatBase(tt.n, tt.base, tt.group, tt.sep)
+			if out != tt.want {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestFactorial(t *testing.T) {
+	tests := []struct {
+		in   int64
+		want int64
+		ok   bool
+	}{
+		{0, 1, true},
+		{1, 1, true},
+		{2, 2, true},
+		{5, 120, true},
+		{10, 3628800, true},
+		{20, 2432902008176640000, true},
+
+		{21, 0, false},
+		{100, 0, false},
+		{-1, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d", tt.in), func(t *testing.T) {
+			out, ok := Factorial(tt.in)
+			if out != tt.want || ok != tt.ok {
+				t.Errorf("\nout:  %#v, %t\nwant: %#v, %t\n", out, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestBinomial(t *testing.T) {
+	tests := []struct {
+		n, k int64
+		want int64
+		ok   bool
+	}{
+		{0, 0, 1, true},
+		{5, 0, 1, true},
+		{5, 5, 1, true},
+		{5, 1, 5, true},
+		{5, 2, 10, true},
+		{10, 3, 120, true},
+		{52, 5, 2598960, true},
+		{30, 15, 155117520, true},
+		{62, 31, 465428353255261088, true},
+		{66, 33, 7219428434016265740, true},
+		{1000, 2, 499500, true},
+
+		{5, 6, 0, true},
+		{5, -1, 0, true},
+
+		{67, 33, 0, false},
+		{100, 50, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d,%d", tt.n, tt.k), func(t *testing.T) {
+			out, ok := Binomial(tt.n, tt.k)
+			if out != tt.want || ok != tt.ok {
+				t.Errorf("\nout:  %#v, %t\nwant: %#v, %t\n", out, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestMinMaxSlice(t *testing.T) {
+	tests := []struct {
+		in       []int64
+		min, max int64
+		ok       bool
+	}{
+		{nil, 0, 0, false},
+		{[]int64{}, 0, 0, false},
+		{[]int64{5}, 5, 5, true},
+		{[]int64{-5}, -5, -5, true},
+		{[]int64{3, 1, 2}, 1, 3, true},
+		{[]int64{7, 1, 7, 3}, 1, 7, true},
+		{[]int64{2, 2, 2}, 2, 2, true},
+		{[]int64{-1, -10, 0}, -10, 0, true},
+		{[]int64{math.MaxInt64, math.MinInt64}, math.MinInt64, math.MaxInt64, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v", tt.in), func(t *testing.T) {
+			min, ok := MinSlice(tt.in)
+			if min != tt.min || ok != tt.ok {
+				t.Errorf("min\nout:  %#v, %t\nwant: %#v, %t\n", min, ok, tt.min, tt.ok)
+			}
+			max, ok := MaxSlice(tt.in)
+			if max != tt.max || ok != tt.ok {
+				t.Errorf("max\nout:  %#v, %t\nwant: %#v, %t\n", max, ok, tt.max, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParseHuman(t *testing.T) {
+	tests := []struct {
+		in      string
+		binary  bool
+		want    int64
+		wantErr string
+	}{
+		{"0", false, 0, ""},
+		{"42", false, 42, ""},
+		{"-42", false, -42, ""},
+		{" 42 ", false, 42, ""},
+		{"9223372036854775807", false, math.MaxInt64, ""},
+		{"1k", false, 1000, ""},
+		{"1K", false, 1000, ""},
+		{"1k", true, 1024, ""},
+		{"1 k", false, 1000, ""},
+		{"2M", false, 2000000, ""},
+		{"2m", true, 2 * 1024 * 1024, ""},
+		{"3G", false, 3000000000, ""},
+		{"3G", true, 3 << 30, ""},
+		{"4T", false, 4000000000000, ""},
+		{"4T", true, 4 << 40, ""},
+		{"1P", false, 1e15, ""},
+		{"1E", true, 1 << 60, ""},
+		{"-2k", false, -2000, ""},
+		{"1.5k", false, 1500, ""},
+		{"1.5k", true, 1536, ""},
+		{"0.5M", false, 500000, ""},
+		{".5k", false, 500, ""},
+		{"1.2345k", false, 1235, ""},
+		{"-1.5k", true, -1536, ""},
+		{"1.5", false, 2, ""},
+		{"1Ki", false, 1024, ""},
+		{"1.5Mi", false, 1572864, ""},
+		{"1ki", true, 1024, ""},
+
+		{"", false, 0, "no number"},
+		{"k", false, 0, "no number"},
+		{"i", false, 0, "invalid unit"},
+		{"1i", false, 0, "invalid unit"},
+		{"1x", false, 0, "invalid number"},
+		{"1kk", false, 0, "invalid number"},
+		{"k1", false, 0, "invalid number"},
+		{"1,5k", false, 0, "invalid number"},
+		{"NaN", false, 0, "invalid number"},
+		{"Inf", false, 0, "invalid number"},
+		{"10E", false, 0, "out of range"},
+		{"8E", true, 0, "out of range"},
+		{"9.3E", false, 0, "out of range"},
+		{"9223372036854775808", false, 0, "out of range"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%t", tt.in, tt.binary), func(t *testing.T) {
+			out, err := ParseHuman(tt.in, tt.binary)
+			if !ztest.ErrorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nout:  %v\nwant: %v\n", err, tt.wantErr)
+			}
+			if out != tt.want {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatHuman(t *testing.T) {
+	tests := []struct {
+		in     int64
+		binary bool
+		want   string
+	}{
+		{0, false, "0"},
+		{999, false, "999"},
+		{-999, false, "-999"},
+		{1000, false, "1k"},
+		{1000, true, "1000"},
+		{1024, true, "1Ki"},
+		{1500, false, "1.5k"},
+		{1536, true, "1.5Ki"},
+		{-1500, false, "-1.5k"},
+		{1234, false, "1.2k"},
+		{1250, false, "1.3k"},
+		{999949, false, "999.9k"},
+		{999999, false, "1M"},
+		{2000000, false, "2M"},
+		{3 << 30, true, "3Gi"},
+		{4000000000000, false, "4T"},
+		{1e15, false, "1P"},
+		{math.MaxInt64, false, "9.2E"},
+		{math.MinInt64, false, "-9.2E"},
+		{math.MaxInt64 / 2, true, "4Ei"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d/%t", tt.in, tt.binary), func(t *testing.T) {
+			out := FormatHuman(tt.in, tt.binary)
+			if out != tt.want {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+
+			back, err := ParseHuman(out, tt.binary)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := math.Abs(float64(back-tt.in)) / math.Max(1, math.Abs(float64(tt.in))); d > 0.05 {
+				t.Errorf("round-trip: %d -> %s -> %d", tt.in, out, back)
+			}
+		})
+	}
+}
+
+func TestGCD(t *testing.T) {
+	tests := []struct {
+		a, b int64
+		gcd  int64
+		lcm  int64
+		ok   bool
+	}{
+		{0, 0, 0, 0, true},
+		{5, 0, 5, 0, true},
+		{0, 5, 5, 0, true},
+		{-5, 0, 5, 0, true},
+		{1, 1, 1, 1, true},
+		{12, 18, 6, 36, true},
+		{18, 12, 6, 36, true},
+		{7, 13, 1, 91, true},  // Coprime
+		{8, 15, 1, 120, true}, // Coprime
+		{-12, 18, 6, 36, true},
+		{12, -18, 6, 36, true},
+		{-12, -18, 6, 36, true},
+		{21, 7, 7, 21, true},
+		{math.MaxInt64, 1, 1, math.MaxInt64, true},
+		{math.MaxInt64, math.MaxInt64, math.MaxInt64, math.MaxInt64, true},
+
+		{math.MaxInt64, 2, 1, 0, false},
+		{1 << 62, 3, 1, 0, false},
+		{math.MinInt64, 1, 1, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d,%d", tt.a, tt.b), func(t *testing.T) {
+			if out := GCD(tt.a, tt.b); out != tt.gcd {
+				t.Errorf("GCD\nout:  %#v\nwant: %#v\n", out, tt.gcd)
+			}
+			out, ok := LCM(tt.a, tt.b)
+			if out != tt.lcm || ok != tt.ok {
+				t.Errorf("LCM\nout:  %#v, %t\nwant: %#v, %t\n", out, ok, tt.lcm, tt.ok)
+			}
+		})
+	}
+}
+
+func TestGCDSlice(t *testing.T) {
+	tests := []struct {
+		in  []int64
+		gcd int64
+		lcm int64
+		ok  bool
+	}{
+		{nil, 0, 1, true},
+		{[]int64{6}, 6, 6, true},
+		{[]int64{-6}, 6, 6, true},
+		{[]int64{4, 6, 8}, 2, 24, true},
+		{[]int64{12, 18, 24}, 6, 72, true},
+		{[]int64{3, 5, 7}, 1, 105, true},
+		{[]int64{0, 4, 6}, 2, 0, true},
+		{[]int64{-4, 6}, 2, 12, true},
+		{[]int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 1, 2520, true},
+		{[]int64{math.MaxInt64, 2, 3}, 1, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v", tt.in), func(t *testing.T) {
+			if out := GCDSlice(tt.in); out != tt.gcd {
+				t.Errorf("GCDSlice\nout:  %#v\nwant: %#v\n", out, tt.gcd)
+			}
+			out, ok := LCMSlice(tt.in)
+			if out != tt.lcm || ok != tt.ok {
+				t.Errorf("LCMSlice\nout:  %#v, %t\nwant: %#v, %t\n", out, ok, tt.lcm, tt.ok)
+			}
+		})
+	}
+}
+
+func TestChunk(t *testing.T) {
+	tests := []struct {
+		in   []int64
+		size int
+		want [][]int64
+	}{
+		{nil, 2, nil},
+		{[]int64{}, 2, nil},
+		{[]int64{1}, 2, [][]int64{{1}}},
+		{[]int64{1, 2, 3, 4}, 2, [][]int64{{1, 2}, {3, 4}}},
+		{[]int64{1, 2, 3, 4, 5}, 2, [][]int64{{1, 2}, {3, 4}, {5}}},
+		{[]int64{1, 2, 3}, 3, [][]int64{{1, 2, 3}}},
+		{[]int64{1, 2, 3}, 10, [][]int64{{1, 2, 3}}},
+		{[]int64{1, 2, 3}, 1, [][]int64{{1}, {2}, {3}}},
+		{[]int64{1, 2, 3}, 0, [][]int64{{1, 2, 3}}},
+		{[]int64{1, 2, 3}, -1, [][]int64{{1, 2, 3}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v/%d", tt.in, tt.size), func(t *testing.T) {
+			out := Chunk(tt.in, tt.size)
+			if !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+		})
+	}
+
+	t.Run("append", func(t *testing.T) {
+		list := []int64{1, 2, 3, 4}
+		c := Chunk(list, 2)
+		_ = append(c[0], 42)
+		if !reflect.DeepEqual(list, []int64{1, 2, 3, 4}) {
+			t.Errorf("list modified: %v", list)
+		}
+	})
+}
+
+func TestIsPrime(t *testing.T) {
+	// Check the small numbers against a sieve.
+	const max = 10000
+	composite := make([]bool, max)
+	for i := 2; i < max; i++ {
+		if !composite[i] {
+			for j := i * i; j < max; j += i {
+				composite[j] = true
+			}
+		}
+	}
+	for i := int64(-10); i < max; i++ {
+		want := i >= 2 && !composite[i]
+		if out := IsPrime(i); out != want {
+			t.Errorf("%d\nout:  %t\nwant: %t\n", i, out, want)
+		}
+	}
+
+	tests := []struct {
+		in   int64
+		want bool
+	}{
+		{2147483647, true},          // 2³¹-1
+		{2305843009213693951, true}, // 2⁶¹-1
+		{9223372036854775783, true}, // Largest int64 prime.
+		{1000000007, true},
+		{999999999989, true},
+		{4611686018427387847, true},
+		{math.MaxInt64, false}, // 7² · 73 · 127 · 337 · 92737 · 649657
+		{1000000007 * 998244353, false},
+		{3215031751, false},          // Strong pseudoprime to bases 2, 3, 5, 7.
+		{3825123056546413051, false}, // Strong pseudoprime to bases 2–23.
+		{561, false},                 // Carmichael number.
+		{4294967297, false},          // 2³²+1 = 641 · 6700417
+		{math.MinInt64, false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d", tt.in), func(t *testing.T) {
+			out := IsPrime(tt.in)
+			if out != tt.want {
+				t.Errorf("\nout:  %t\nwant: %t\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextPrime(t *testing.T) {
+	tests := []struct {
+		in, want int64
+	}{
+		{math.MinInt64, 2},
+		{-1, 2},
+		{0, 2},
+		{1, 2},
+		{2, 3},
+		{3, 5},
+		{4, 5},
+		{13, 17},
+		{14, 17},
+		{89, 97},
+		{1000000000, 1000000007},
+		{2147483646, 2147483647},
+		{2147483647, 2147483659},
+		{9223372036854775782, 9223372036854775783},
+		{9223372036854775783, 0},
+		{math.MaxInt64, 0},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d", tt.in), func(t *testing.T) {
+			out := NextPrime(tt.in)
+			if out != tt.want {
+				t.Errorf("\nout:  %d\nwant: %d\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestToBytes(t *testing.T) {
+	tests := []struct {
+		in   int64
+		want []byte
+	}{
+		{0, []byte{0x00}},
+		{-1, []byte{0x01}},
+		{1, []byte{0x02}},
+		{-64, []byte{0x7f}},
+		{63, []byte{0x7e}},
+		{64, []byte{0x80}},
+		{127, []byte{0xfe}},
+		{128, []byte{0x01, 0x00}},
+		{-129, []byte{0x01, 0x01}},
+		{math.MaxInt32, []byte{0xff, 0xff, 0xff, 0xfe}},
+		{math.MinInt32, []byte{0xff, 0xff, 0xff, 0xff}},
+		{math.MaxInt64, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe}},
+		{math.MinInt64, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d", tt.in), func(t *testing.T) {
+			out := ToBytes(tt.in)
+			if !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+			back, err := FromBytes(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if back != tt.in {
+				t.Errorf("round-trip\nout:  %d\nwant: %d\n", back, tt.in)
+			}
+		})
+	}
+
+	t.Run("random", func(t *testing.T) {
+		for i := 0; i < 10000; i++ {
+			n := int64(rand.Uint64()) >> uint(rand.Intn(64))
+			back, err := FromBytes(ToBytes(n))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if back != n {
+				t.Fatalf("round-trip\nout:  %d\nwant: %d\n", back, n)
+			}
+		}
+	})
+}
+
+func TestFromBytes(t *testing.T) {
+	tests := []struct {
+		in      []byte
+		want    int64
+		wantErr string
+	}{
+		{[]byte{0x00, 0x02}, 1, ""},
+		{nil, 0, "invalid length 0"},
+		{[]byte{}, 0, "invalid length 0"},
+		{make([]byte, 9), 0, "invalid length 9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%x", tt.in), func(t *testing.T) {
+			out, err := FromBytes(tt.in)
+			if !ztest.ErrorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nout:  %v\nwant: %v\n", err, tt.wantErr)
+			}
+			if out != tt.want {
+				t.Errorf("\nout:  %d\nwant: %d\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	uniform := make([]int64, 100)
+	for i := range uniform {
+		uniform[i] = int64(i)
+	}
+
+	tests := []struct {
+		in      []int64
+		buckets int
+		bounds  []int64
+		counts  []int64
+	}{
+		{nil, 5, nil, nil},
+		{[]int64{1, 2}, 0, nil, nil},
+		{[]int64{1, 2}, -1, nil, nil},
+		{[]int64{7}, 5, []int64{7}, []int64{1}},
+		{[]int64{7, 7, 7}, 5, []int64{7}, []int64{3}},
+		{[]int64{1, 2, 2, 3}, 5, []int64{1, 2, 3}, []int64{1, 2, 1}},
+		{uniform, 4, []int64{0, 25, 50, 75}, []int64{25, 25, 25, 25}},
+		{uniform, 10, []int64{0, 10, 20, 30, 40, 50, 60, 70, 80, 90},
+			[]int64{10, 10, 10, 10, 10, 10, 10, 10, 10, 10}},
+		{uniform, 3, []int64{0, 34, 67}, []int64{34, 33, 33}},
+		{uniform, 1, []int64{0}, []int64{100}},
+		{[]int64{-10, -5, 0, 5, 9}, 2, []int64{-10, 0}, []int64{2, 3}},
+		{[]int64{math.MinInt64, 0, math.MaxInt64}, 2, []int64{math.MinInt64, 0}, []int64{1, 2}},
+		{[]int64{math.MinInt64, -1, 1, math.MaxInt64}, 4,
+			[]int64{math.MinInt64, math.MinInt64 / 2, 0, math.MaxInt64/2 + 1}, []int64{1, 1, 1, 1}},
+		{[]int64{math.MaxInt64 - 1, math.MaxInt64}, 2, []int64{math.MaxInt64 - 1, math.MaxInt64}, []int64{1, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v/%d", tt.in, tt.buckets), func(t *testing.T) {
+			bounds, counts := Histogram(tt.in, tt.buckets)
+			if !reflect.DeepEqual(bounds, tt.bounds) {
+				t.Errorf("bounds\nout:  %#v\nwant: %#v\n", bounds, tt.bounds)
+			}
+			if !reflect.DeepEqual(counts, tt.counts) {
+				t.Errorf("counts\nout:  %#v\nwant: %#v\n", counts, tt.counts)
+			}
+		})
+	}
+
+	// Make sure every value is counted in the bucket the bounds say it's in.
+	t.Run("random", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			values := make([]int64, rand.Intn(50)+1)
+			for j := range values {
+				values[j] = rand.Int63n(1000) - 500
+			}
+			bounds, counts := Histogram(values, rand.Intn(20)+1)
+
+			want := make([]int64, len(bounds))
+			for _, v := range values {
+				j := len(bounds) - 1
+				for j > 0 && v < bounds[j] {
+					j--
+				}
+				want[j]++
+			}
+			if !reflect.DeepEqual(counts, want) {
+				t.Fatalf("\nvalues: %v\nbounds: %v\nout:    %v\nwant:   %v\n", values, bounds, counts, want)
+			}
+		}
+	})
+}
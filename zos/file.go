@@ -0,0 +1,277 @@
+package zos
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Exists reports if path exists.
+//
+// This returns false on any error, including permission errors; use ExistsE
+// if you need to distinguish between "doesn't exist" and other errors.
+func Exists(path string) bool {
+	ok, _ := ExistsE(path)
+	return ok
+}
+
+// ExistsE reports if path exists.
+//
+// A path that doesn't exist is not an error; any other error from os.Stat()
+// is returned.
+func ExistsE(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// IsDir reports if path exists and is a directory.
+//
+// This returns false on any error; use IsDirE if you need the error.
+func IsDir(path string) bool {
+	ok, _ := IsDirE(path)
+	return ok
+}
+
+// IsDirE reports if path exists and is a directory.
+//
+// A path that doesn't exist is not an error; any other error from os.Stat()
+// is returned.
+func IsDirE(path string) (bool, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return st.IsDir(), nil
+}
+
+// IsFile reports if path exists and is a regular file.
+//
+// This returns false on any error; use IsFileE if you need the error.
+func IsFile(path string) bool {
+	ok, _ := IsFileE(path)
+	return ok
+}
+
+// IsFileE reports if path exists and is a regular file.
+//
+// A path that doesn't exist is not an error; any other error from os.Stat()
+// is returned.
+func IsFileE(path string) (bool, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return st.Mode().IsRegular(), nil
+}
+
+// CopyFile copies the file src to dst, preserving the permission bits.
+//
+// It's an error if dst already exists, unless overwrite is true. The data is
+// synced to disk before returning. src must be a regular file.
+func CopyFile(src, dst string, overwrite bool) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("zos.CopyFile: %w", err)
+	}
+	defer in.Close()
+
+	st, err := in.Stat()
+	if err != nil {
+		return fmt.Errorf("zos.CopyFile: %w", err)
+	}
+	if !st.Mode().IsRegular() {
+		return fmt.Errorf("zos.CopyFile: %q is not a regular file", src)
+	}
+
+	// Opening dst truncates it, which would destroy src if they're the same
+	// file (e.g. through a symlink or hard link).
+	dstSt, err := os.Stat(dst)
+	if err == nil && os.SameFile(st, dstSt) {
+		return fmt.Errorf("zos.CopyFile: %q and %q are the same file", src, dst)
+	}
+	created := err != nil
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flags |= os.O_EXCL
+		created = true
+	}
+	mode := ReadPermissions(st.Mode()).FileMode()
+	out, err := os.OpenFile(dst, flags, mode)
+	if err != nil {
+		return fmt.Errorf("zos.CopyFile: %w", err)
+	}
+
+	err = copyFile(in, out, mode)
+	if err != nil {
+		out.Close()
+		if created { // Don't leave a partial file behind.
+			os.Remove(dst)
+		}
+		return fmt.Errorf("zos.CopyFile: %w", err)
+	}
+	err = out.Close()
+	if err != nil {
+		return fmt.Errorf("zos.CopyFile: %w", err)
+	}
+	return nil
+}
+
+func copyFile(in, out *os.File, mode os.FileMode) error {
+	_, err := io.Copy(out, in)
+	if err != nil {
+		return err
+	}
+	// The mode passed to OpenFile() is affected by the umask, and isn't used
+	// at all if the file already exists.
+	err = out.Chmod(mode)
+	if err != nil {
+		return err
+	}
+	return out.Sync()
+}
+
+// WriteFileAtomic writes data to the file path, like ioutil.WriteFile(), but
+// ensures that readers will never see a partially written file.
+//
+// The data is written to a temporary file in the same directory, which is
+// synced to disk and then renamed to path. The temporary file is removed on
+// errors. The permissions are set to exactly perm, regardless of the umask,
+// and replace the permissions of path if it already exists.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	fp, err := ioutil.TempFile(dir, "."+base+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("zos.WriteFileAtomic: %w", err)
+	}
+
+	ok := false
+	defer func() {
+		if !ok {
+			fp.Close()
+			os.Remove(fp.Name())
+		}
+	}()
+
+	_, err = fp.Write(data)
+	if err != nil {
+		return fmt.Errorf("zos.WriteFileAtomic: %w", err)
+	}
+	err = fp.Chmod(ReadPermissions(perm).FileMode())
+	if err != nil {
+		return fmt.Errorf("zos.WriteFileAtomic: %w", err)
+	}
+	err = fp.Sync()
+	if err != nil {
+		return fmt.Errorf("zos.WriteFileAtomic: %w", err)
+	}
+	err = fp.Close()
+	if err != nil {
+		return fmt.Errorf("zos.WriteFileAtomic: %w", err)
+	}
+	err = os.Rename(fp.Name(), path)
+	if err != nil {
+		return fmt.Errorf("zos.WriteFileAtomic: %w", err)
+	}
+	ok = true
+
+	// Sync the directory to make sure the rename is persisted; this isn't
+	// supported on all systems, so errors are ignored.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// MkdirAll creates the directory path with all its parents, like
+// os.MkdirAll().
+//
+// Unlike os.MkdirAll(), the permissions of all directories it creates are set
+// to exactly p, regardless of the umask. The permissions of path are also set
+// if it already exists; the permissions of existing parent directories are
+// never changed.
+func MkdirAll(path string, p Permissions) error {
+	path = filepath.Clean(path)
+
+	// Find all directories that don't exist yet.
+	var create []string
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(dir); err == nil || !os.IsNotExist(err) {
+			break
+		}
+		create = append(create, dir)
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	mode := p.FileMode()
+	err := os.MkdirAll(path, mode)
+	if err != nil {
+		return fmt.Errorf("zos.MkdirAll: %w", err)
+	}
+
+	if len(create) == 0 {
+		create = []string{path}
+	}
+	for _, dir := range create {
+		err := os.Chmod(dir, mode)
+		if err != nil {
+			return fmt.Errorf("zos.MkdirAll: %w", err)
+		}
+	}
+	return nil
+}
+
+// TempFile creates a new temporary file in the default temporary directory,
+// and returns the opened file and a function to remove it.
+//
+// The pattern is used as with ioutil.TempFile(). The cleanup function also
+// closes the file if it's still open.
+//
+//   fp, clean, err := zos.TempFile("x-*.json")
+//   if err != nil {
+//       return err
+//   }
+//   defer clean()
+func TempFile(pattern string) (*os.File, func(), error) {
+	fp, err := ioutil.TempFile("", pattern)
+	if err != nil {
+		return nil, func() {}, fmt.Errorf("zos.TempFile: %w", err)
+	}
+	return fp, func() {
+		fp.Close()
+		os.Remove(fp.Name())
+	}, nil
+}
+
+// TempDir creates a new temporary directory in the default temporary
+// directory, and returns the path and a function to remove it and everything
+// it contains.
+//
+// The pattern is used as with ioutil.TempDir().
+func TempDir(pattern string) (string, func(), error) {
+	dir, err := ioutil.TempDir("", pattern)
+	if err != nil {
+		return "", func() {}, fmt.Errorf("zos.TempDir: %w", err)
+	}
+	return dir, func() { os.RemoveAll(dir) }, nil
+}
@@ -0,0 +1,377 @@
+package zos
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"zgo.at/zstd/ztest"
+)
+
+func TestExists(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "zos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	file := filepath.Join(tmp, "file")
+	err = ioutil.WriteFile(file, []byte("x"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path                  string
+		exists, isDir, isFile bool
+	}{
+		{tmp, true, true, false},
+		{file, true, false, true},
+		{filepath.Join(tmp, "nonexistent"), false, false, false},
+		{filepath.Join(tmp, "nonexistent", "path"), false, false, false},
+		{"/dev/null", true, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if out := Exists(tt.path); out != tt.exists {
+				t.Errorf("Exists: %t; want %t", out, tt.exists)
+			}
+			if out := IsDir(tt.path); out != tt.isDir {
+				t.Errorf("IsDir: %t; want %t", out, tt.isDir)
+			}
+			if out := IsFile(tt.path); out != tt.isFile {
+				t.Errorf("IsFile: %t; want %t", out, tt.isFile)
+			}
+
+			for _, f := range []func(string) (bool, error){ExistsE, IsDirE, IsFileE} {
+				if _, err := f(tt.path); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		// Stat on a path "inside" a file gives ENOTDIR, rather than ENOENT.
+		path := filepath.Join(file, "sub")
+		for _, f := range []func(string) (bool, error){ExistsE, IsDirE, IsFileE} {
+			ok, err := f(path)
+			if ok || err == nil {
+				t.Errorf("wrong return: %t, %v", ok, err)
+			}
+		}
+		if Exists(path) {
+			t.Error("Exists returned true")
+		}
+	})
+}
+
+func TestCopyFile(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "zos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	src := filepath.Join(tmp, "src")
+	err = ioutil.WriteFile(src, []byte("data"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chmod(src, 0751)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(t *testing.T, dst string) {
+		t.Helper()
+		data, err := ioutil.ReadFile(dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "data" {
+			t.Errorf("wrong data: %q", data)
+		}
+		st, err := os.Stat(dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.Mode() != 0751 {
+			t.Errorf("wrong mode: %s", st.Mode())
+		}
+	}
+
+	t.Run("new", func(t *testing.T) {
+		dst := filepath.Join(tmp, "new")
+		err := CopyFile(src, dst, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, dst)
+	})
+
+	t.Run("exists", func(t *testing.T) {
+		dst := filepath.Join(tmp, "exists")
+		err := ioutil.WriteFile(dst, []byte("old data, longer"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = CopyFile(src, dst, false)
+		if !os.IsExist(errors.Unwrap(err)) {
+			t.Fatalf("wrong error: %v", err)
+		}
+		data, _ := ioutil.ReadFile(dst)
+		if string(data) != "old data, longer" {
+			t.Errorf("data was overwritten: %q", data)
+		}
+
+		err = CopyFile(src, dst, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, dst)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		err := CopyFile(filepath.Join(tmp, "nonexistent"), filepath.Join(tmp, "x"), false)
+		if !os.IsNotExist(errors.Unwrap(err)) {
+			t.Errorf("wrong error: %v", err)
+		}
+
+		err = CopyFile(tmp, filepath.Join(tmp, "x"), false)
+		if !ztest.ErrorContains(err, "not a regular file") {
+			t.Errorf("wrong error: %v", err)
+		}
+	})
+
+	t.Run("same file", func(t *testing.T) {
+		link := filepath.Join(tmp, "link")
+		err := os.Symlink(src, link)
+		if err != nil {
+			t.Skip(err)
+		}
+		hard := filepath.Join(tmp, "hard")
+		err = os.Link(src, hard)
+		if err != nil {
+			t.Skip(err)
+		}
+
+		for _, dst := range []string{src, link, hard, filepath.Join(tmp, ".", "src")} {
+			for _, overwrite := range []bool{true, false} {
+				err := CopyFile(src, dst, overwrite)
+				if !ztest.ErrorContains(err, "are the same file") {
+					t.Errorf("%s/%t: wrong error: %v", dst, overwrite, err)
+				}
+			}
+		}
+		check(t, src)
+	})
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "zos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	check := func(t *testing.T, path, want string, mode os.FileMode) {
+		t.Helper()
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("wrong data\nout:  %q\nwant: %q\n", data, want)
+		}
+		st, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.Mode() != mode {
+			t.Errorf("wrong mode\nout:  %s\nwant: %s\n", st.Mode(), mode)
+		}
+
+		// Make sure no temporary files are left behind.
+		ls, err := ioutil.ReadDir(filepath.Dir(path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range ls {
+			if strings.Contains(f.Name(), ".tmp-") {
+				t.Errorf("temporary file left behind: %s", f.Name())
+			}
+		}
+	}
+
+	t.Run("new", func(t *testing.T) {
+		path := filepath.Join(tmp, "new")
+		err := WriteFileAtomic(path, []byte("data"), 0640)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, path, "data", 0640)
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		path := filepath.Join(tmp, "overwrite")
+		err := ioutil.WriteFile(path, []byte("old data, longer"), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = WriteFileAtomic(path, []byte("new"), 0755|os.ModeSetuid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, path, "new", 0755|os.ModeSetuid)
+	})
+
+	t.Run("umask", func(t *testing.T) {
+		path := filepath.Join(tmp, "umask")
+		err := WriteFileAtomic(path, nil, 0777)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, path, "", 0777)
+	})
+
+	t.Run("error cleanup", func(t *testing.T) {
+		// Renaming a file over a non-empty directory fails.
+		path := filepath.Join(tmp, "dir")
+		err := os.MkdirAll(filepath.Join(path, "sub"), 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = WriteFileAtomic(path, []byte("data"), 0600)
+		if err == nil {
+			t.Fatal("error is nil")
+		}
+		ls, err := ioutil.ReadDir(tmp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range ls {
+			if strings.Contains(f.Name(), ".tmp-") {
+				t.Errorf("temporary file left behind: %s", f.Name())
+			}
+		}
+	})
+
+	t.Run("no dir", func(t *testing.T) {
+		err := WriteFileAtomic(filepath.Join(tmp, "nonexistent", "file"), nil, 0600)
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("wrong error: %v", err)
+		}
+	})
+}
+
+func TestMkdirAll(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "zos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	err = os.Chmod(tmp, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mode := func(t *testing.T, path string) os.FileMode {
+		t.Helper()
+		st, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return st.Mode().Perm() | st.Mode()&(os.ModeSetgid|os.ModeSticky)
+	}
+
+	t.Run("new", func(t *testing.T) {
+		// 0777 would be 0755 with the usual umask.
+		err := MkdirAll(filepath.Join(tmp, "a", "b", "c"), ReadPermissions(0777))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range []string{"a", "a/b", "a/b/c"} {
+			if m := mode(t, filepath.Join(tmp, p)); m != 0777 {
+				t.Errorf("%s: wrong mode %s", p, m)
+			}
+		}
+		if m := mode(t, tmp); m != 0700 {
+			t.Errorf("mode of existing parent changed: %s", m)
+		}
+	})
+
+	t.Run("existing", func(t *testing.T) {
+		err := MkdirAll(filepath.Join(tmp, "a", "b", "c"), ReadPermissions(os.ModeSticky|0750))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m := mode(t, filepath.Join(tmp, "a/b/c")); m != os.ModeSticky|0750 {
+			t.Errorf("wrong mode %s", m)
+		}
+		if m := mode(t, filepath.Join(tmp, "a/b")); m != 0777 {
+			t.Errorf("mode of existing parent changed: %s", m)
+		}
+	})
+
+	t.Run("file", func(t *testing.T) {
+		file := filepath.Join(tmp, "file")
+		err := ioutil.WriteFile(file, nil, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = MkdirAll(filepath.Join(file, "dir"), ReadPermissions(0755))
+		if err == nil {
+			t.Fatal("error is nil")
+		}
+	})
+}
+
+func TestTempFile(t *testing.T) {
+	fp, clean, err := TempFile("zos-*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(filepath.Base(fp.Name()), "zos-") || !strings.HasSuffix(fp.Name(), ".txt") {
+		t.Errorf("wrong name: %q", fp.Name())
+	}
+	_, err = fp.WriteString("data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsFile(fp.Name()) {
+		t.Fatal("file doesn't exist")
+	}
+
+	clean()
+	if Exists(fp.Name()) {
+		t.Error("file still exists after clean()")
+	}
+}
+
+func TestTempDir(t *testing.T) {
+	dir, clean, err := TempDir("zos-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsDir(dir) {
+		t.Fatal("dir doesn't exist")
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "file"), []byte("data"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clean()
+	if Exists(dir) {
+		t.Error("dir still exists after clean()")
+	}
+}
@@ -0,0 +1,75 @@
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package zos
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSignalContext(t *testing.T) {
+	wait := func(t *testing.T, ctx context.Context) {
+		t.Helper()
+		select {
+		case <-ctx.Done():
+		case <-time.After(2 * time.Second):
+			t.Fatal("context not cancelled")
+		}
+	}
+
+	t.Run("signal", func(t *testing.T) {
+		ctx, stop := SignalContext(syscall.SIGUSR1)
+		defer stop()
+
+		if ctx.Err() != nil {
+			t.Fatalf("already cancelled: %v", ctx.Err())
+		}
+		err := syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wait(t, ctx)
+		if ctx.Err() != context.Canceled {
+			t.Errorf("wrong error: %v", ctx.Err())
+		}
+	})
+
+	t.Run("default", func(t *testing.T) {
+		ctx, stop := SignalContext()
+		defer stop()
+
+		err := syscall.Kill(os.Getpid(), syscall.SIGTERM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wait(t, ctx)
+	})
+
+	t.Run("other signal", func(t *testing.T) {
+		ctx, stop := SignalContext(syscall.SIGUSR1)
+		defer stop()
+		// Make sure SIGUSR2 doesn't kill the test.
+		signal.Ignore(syscall.SIGUSR2)
+		defer signal.Reset(syscall.SIGUSR2)
+
+		err := syscall.Kill(os.Getpid(), syscall.SIGUSR2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+		if ctx.Err() != nil {
+			t.Errorf("cancelled: %v", ctx.Err())
+		}
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		ctx, stop := SignalContext(syscall.SIGUSR1)
+		stop()
+		stop()
+		wait(t, ctx)
+	})
+}
@@ -1,7 +1,18 @@
 // Package zos implements functions for interfacing with the operating system.
 package zos
 
-import "os"
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
 
 const (
 	Setuid uint32 = 1 << (12 - 1 - iota)
@@ -22,6 +33,10 @@ type Permissions struct {
 	User  Permission
 	Group Permission
 	Other Permission
+
+	Setuid bool
+	Setgid bool
+	Sticky bool
 }
 
 type Permission struct {
@@ -31,9 +46,15 @@ type Permission struct {
 }
 
 // ReadPermissions reads all Unix permissions.
+//
+// The setuid, setgid, and sticky bits are read from both the os.ModeSetuid etc.
+// bits and the Unix-style Setuid etc. bits.
 func ReadPermissions(mode os.FileMode) Permissions {
 	m := uint32(mode)
 	return Permissions{
+		Setuid: mode&os.ModeSetuid != 0 || m&Setuid != 0,
+		Setgid: mode&os.ModeSetgid != 0 || m&Setgid != 0,
+		Sticky: mode&os.ModeSticky != 0 || m&Sticky != 0,
 		User: Permission{
 			Read:    m&UserRead != 0,
 			Write:   m&UserWrite != 0,
@@ -52,6 +73,160 @@ func ReadPermissions(mode os.FileMode) Permissions {
 	}
 }
 
+// FileMode gets the os.FileMode for these permissions.
+//
+// The setuid, setgid, and sticky bits are set as os.ModeSetuid, os.ModeSetgid,
+// and os.ModeSticky, which is what os.Chmod() expects. File type bits such as
+// os.ModeDir are not stored in Permissions, and are never set.
+func (p Permissions) FileMode() os.FileMode {
+	var m uint32
+	for _, b := range []struct {
+		set  bool
+		flag uint32
+	}{
+		{p.User.Read, UserRead}, {p.User.Write, UserWrite}, {p.User.Execute, UserExecute},
+		{p.Group.Read, GroupRead}, {p.Group.Write, GroupWrite}, {p.Group.Execute, GroupExecute},
+		{p.Other.Read, OtherRead}, {p.Other.Write, OtherWrite}, {p.Other.Execute, OtherExecute},
+	} {
+		if b.set {
+			m |= b.flag
+		}
+	}
+
+	mode := os.FileMode(m)
+	if p.Setuid {
+		mode |= os.ModeSetuid
+	}
+	if p.Setgid {
+		mode |= os.ModeSetgid
+	}
+	if p.Sticky {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
+
+// Apply the umask to mode, returning the permissions a new file or directory
+// created with mode will actually get.
+//
+// Only the permission bits are masked; other bits such as os.ModeDir are left
+// alone.
+func Apply(mode os.FileMode, umask uint32) os.FileMode {
+	return mode &^ os.FileMode(umask&0777)
+}
+
+// String gets the permissions in the symbolic form used by ls, e.g.
+// "rwxr-xr--".
+//
+// The setuid and setgid bits are displayed as "s" in the user or group execute
+// position ("S" if the execute bit isn't set), and the sticky bit as "t" in the
+// other execute position ("T" if the execute bit isn't set).
+func (p Permissions) String() string {
+	b := []byte("---------")
+	for i, pp := range []Permission{p.User, p.Group, p.Other} {
+		if pp.Read {
+			b[i*3] = 'r'
+		}
+		if pp.Write {
+			b[i*3+1] = 'w'
+		}
+		if pp.Execute {
+			b[i*3+2] = 'x'
+		}
+	}
+
+	special := func(i int, set bool, c byte) {
+		if !set {
+			return
+		}
+		if b[i] == 'x' {
+			b[i] = c
+		} else {
+			b[i] = c - 'a' + 'A'
+		}
+	}
+	special(2, p.Setuid, 's')
+	special(5, p.Setgid, 's')
+	special(8, p.Sticky, 't')
+	return string(b)
+}
+
+// Octal gets the permissions as a 4-digit octal number, e.g. "0754" or "4755".
+func (p Permissions) Octal() string {
+	m := uint32(p.FileMode().Perm())
+	if p.Setuid {
+		m |= Setuid
+	}
+	if p.Setgid {
+		m |= Setgid
+	}
+	if p.Sticky {
+		m |= Sticky
+	}
+	return fmt.Sprintf("%04o", m)
+}
+
+// ParseSymbolic parses permissions in the symbolic notation as used by ls, e.g.
+// "rwxr-xr-x"; this is the reverse of Permissions.String().
+//
+// The string can optionally start with a file type character (e.g.
+// "drwxr-xr-x"), which is ignored.
+func ParseSymbolic(s string) (Permissions, error) {
+	if len(s) == 10 && strings.IndexByte("-dlcbpsDL", s[0]) > -1 {
+		s = s[1:]
+	}
+	if len(s) != 9 {
+		return Permissions{}, fmt.Errorf("zos.ParseSymbolic: wrong length for %q: must be 9 or 10 characters", s)
+	}
+
+	var (
+		p   Permissions
+		pp  = []*Permission{&p.User, &p.Group, &p.Other}
+		spc = []*bool{&p.Setuid, &p.Setgid, &p.Sticky}
+	)
+	for i := range pp {
+		r, w, x := s[i*3], s[i*3+1], s[i*3+2]
+		if (r != 'r' && r != '-') || (w != 'w' && w != '-') {
+			return Permissions{}, fmt.Errorf("zos.ParseSymbolic: invalid character in %q", s)
+		}
+		pp[i].Read, pp[i].Write = r == 'r', w == 'w'
+
+		c := byte('s')
+		if i == 2 {
+			c = 't'
+		}
+		switch x {
+		case '-':
+		case 'x':
+			pp[i].Execute = true
+		case c:
+			pp[i].Execute, *spc[i] = true, true
+		case c - 'a' + 'A':
+			*spc[i] = true
+		default:
+			return Permissions{}, fmt.Errorf("zos.ParseSymbolic: invalid character in %q", s)
+		}
+	}
+	return p, nil
+}
+
+// ParseOctal parses permissions in the octal notation, e.g. "755", "0755", or
+// "4755"; this is the reverse of Permissions.Octal().
+func ParseOctal(s string) (Permissions, error) {
+	o := strings.TrimPrefix(strings.TrimPrefix(s, "0o"), "0O")
+	if o == "" || len(o) > 5 {
+		return Permissions{}, fmt.Errorf("zos.ParseOctal: wrong length for %q", s)
+	}
+	m, err := strconv.ParseUint(o, 8, 32)
+	if err != nil {
+		return Permissions{}, fmt.Errorf("zos.ParseOctal: invalid octal number %q", s)
+	}
+	if m > 07777 {
+		return Permissions{}, fmt.Errorf("zos.ParseOctal: %q is out of range", s)
+	}
+	return ReadPermissions(os.FileMode(m)), nil
+}
+
 // Arg gets the nth argument from os.Args, or an empty string if os.Args is too
 // short.
 func Arg(n int) string {
@@ -60,3 +235,240 @@ func Arg(n int) string {
 	}
 	return os.Args[n]
 }
+
+// ArgDefault gets the nth argument from os.Args, or def if os.Args is too
+// short.
+func ArgDefault(n int, def string) string {
+	if n > len(os.Args)-1 {
+		return def
+	}
+	return os.Args[n]
+}
+
+// Args gets all arguments from os.Args, without the program name.
+func Args() []string {
+	if len(os.Args) < 2 {
+		return nil
+	}
+	return os.Args[1:]
+}
+
+// SafeJoin joins base and userPath, ensuring that the result doesn't escape
+// base.
+//
+// This is intended for paths from user input, such as an URL path. An absolute
+// userPath is interpreted as relative to base, so "/etc/passwd" becomes
+// "base/etc/passwd". It's an error if the cleaned path is outside of base, for
+// example with "../../etc/passwd".
+func SafeJoin(base, userPath string) (string, error) {
+	base = filepath.Clean(base)
+	path := filepath.Join(base, userPath)
+
+	rel, err := filepath.Rel(base, path)
+	if err != nil {
+		return "", fmt.Errorf("zos.SafeJoin: %w", err)
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("zos.SafeJoin: %q is outside of %q", userPath, base)
+	}
+	return path, nil
+}
+
+// WatchFile polls path every interval and sends on the returned channel when
+// its modification time or size changes.
+//
+// Removing or (re)creating the file is also reported as a change. Changes are
+// coalesced if the previous one hasn't been received yet, so a slow receiver
+// never blocks the watcher.
+//
+// The returned function stops the watcher and closes the channel; it's safe to
+// call more than once. It's an error if interval is 0 or lower, or if path
+// can't be stat'd.
+func WatchFile(path string, interval time.Duration) (<-chan struct{}, func(), error) {
+	if interval <= 0 {
+		return nil, nil, fmt.Errorf("zos.WatchFile: interval must be positive, not %s", interval)
+	}
+	prev, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("zos.WatchFile: %w", err)
+	}
+
+	var (
+		ch   = make(chan struct{}, 1)
+		done = make(chan struct{})
+		exit = make(chan struct{})
+		once sync.Once
+	)
+	go func() {
+		defer close(exit)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		var prevErr error
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				st, err := os.Stat(path)
+				changed := false
+				switch {
+				case err != nil && prevErr != nil:
+					// Still doesn't exist (or can't be read).
+				case err != nil || prevErr != nil:
+					changed = true
+				case !st.ModTime().Equal(prev.ModTime()) || st.Size() != prev.Size():
+					changed = true
+				}
+				prev, prevErr = st, err
+
+				if changed {
+					select {
+					case ch <- struct{}{}:
+					default:
+					}
+				}
+			}
+		}
+	}()
+
+	return ch, func() {
+		once.Do(func() {
+			close(done)
+			<-exit
+			close(ch)
+		})
+	}, nil
+}
+
+// GetenvDefault gets the environment variable key, or def if it's not set or
+// empty.
+func GetenvDefault(key, def string) string {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+// GetenvInt gets the environment variable key as an int, or def if it's not
+// set or can't be parsed as an int.
+func GetenvInt(key string, def int) int {
+	v, err := strconv.Atoi(strings.TrimSpace(os.Getenv(key)))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// GetenvBool gets the environment variable key as a bool, or def if it's not
+// set or can't be parsed as a bool.
+//
+// Accepted values are 1, t, true, y, yes, on and 0, f, false, n, no, off (case
+// insensitive).
+func GetenvBool(key string, def bool) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(key))) {
+	case "1", "t", "true", "y", "yes", "on":
+		return true
+	case "0", "f", "false", "n", "no", "off":
+		return false
+	}
+	return def
+}
+
+// GetenvDuration gets the environment variable key as a time.Duration, or def
+// if it's not set or can't be parsed with time.ParseDuration().
+func GetenvDuration(key string, def time.Duration) time.Duration {
+	v, err := time.ParseDuration(strings.TrimSpace(os.Getenv(key)))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// WithWorkdir changes the working directory to dir, runs fn, and changes the
+// working directory back to what it was.
+//
+// The working directory is restored even if fn returns an error or panics.
+//
+// Note that the working directory is global to the process, so this affects
+// all goroutines; it's not safe to use concurrently.
+func WithWorkdir(dir string, fn func() error) (err error) {
+	orig, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("zos.WithWorkdir: %w", err)
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		return fmt.Errorf("zos.WithWorkdir: %w", err)
+	}
+	defer func() {
+		cdErr := os.Chdir(orig)
+		if cdErr != nil && err == nil {
+			err = fmt.Errorf("zos.WithWorkdir: %w", cdErr)
+		}
+	}()
+
+	return fn()
+}
+
+// SelfPath gets the absolute path to the executable of the current process,
+// with all symlinks resolved.
+func SelfPath() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("zos.SelfPath: %w", err)
+	}
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return "", fmt.Errorf("zos.SelfPath: %w", err)
+	}
+	return exe, nil
+}
+
+// ExecutableDir gets the directory of the executable of the current process,
+// with all symlinks resolved.
+//
+// This is useful for locating files relative to the binary.
+func ExecutableDir() (string, error) {
+	exe, err := SelfPath()
+	if err != nil {
+		return "", fmt.Errorf("zos.ExecutableDir: %w", err)
+	}
+	return filepath.Dir(exe), nil
+}
+
+// SignalContext returns a context that's cancelled when one of the signals is
+// received, defaulting to os.Interrupt (SIGINT) and SIGTERM.
+//
+// The stop function cancels the context and unregisters the signal handler,
+// which restores the default behaviour for the signals. It should be called
+// when the context is no longer needed, and it's safe to call it more than
+// once.
+//
+//   ctx, stop := zos.SignalContext()
+//   defer stop()
+func SignalContext(sigs ...os.Signal) (context.Context, func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	go func() {
+		select {
+		case <-ch:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			cancel()
+		})
+	}
+}
@@ -2,7 +2,11 @@
 
 package zos
 
-import "os"
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+)
 
 // Readable reports if the file is readable by the current user.
 //
@@ -13,3 +17,52 @@ func Readable(s os.FileInfo) (bool, error) { return true, nil }
 //
 // This is a stub for non-POSIX systems which always returns true.
 func Writable(s os.FileInfo) (bool, error) { return true, nil }
+
+// IsReadable reports if the file at path is readable by the current user.
+//
+// This attempts to open the file for reading on non-POSIX systems.
+func IsReadable(path string) (bool, error) {
+	fp, err := os.Open(path)
+	if err != nil {
+		if os.IsPermission(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("zos.IsReadable: %w", err)
+	}
+	fp.Close()
+	return true, nil
+}
+
+// IsWritable reports if the file at path is writable by the current user.
+//
+// This attempts to open the file for writing (or create a temporary file in it
+// for directories) on non-POSIX systems.
+func IsWritable(path string) (bool, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("zos.IsWritable: %w", err)
+	}
+
+	var fp *os.File
+	if st.IsDir() {
+		fp, err = ioutil.TempFile(path, "zos-writable")
+		if err == nil {
+			defer os.Remove(fp.Name())
+		}
+	} else {
+		fp, err = os.OpenFile(path, os.O_WRONLY, 0)
+	}
+	if err != nil {
+		if os.IsPermission(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("zos.IsWritable: %w", err)
+	}
+	fp.Close()
+	return true, nil
+}
+
+// Umask returns the current umask.
+//
+// This is a stub for non-POSIX systems which always returns 0.
+func Umask() uint32 { return 0 }
@@ -0,0 +1,468 @@
+package zos
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"zgo.at/zstd/ztest"
+)
+
+func TestSafeJoin(t *testing.T) {
+	tests := []struct {
+		base, userPath string
+		want, wantErr  string
+	}{
+		{"/srv", "file", "/srv/file", ""},
+		{"/srv", "dir/file", "/srv/dir/file", ""},
+		{"/srv/", "./dir//file", "/srv/dir/file", ""},
+		{"/srv", "dir/../file", "/srv/file", ""},
+		{"/srv", "", "/srv", ""},
+		{"/srv", "/etc/passwd", "/srv/etc/passwd", ""},
+		{"srv", "file", "srv/file", ""},
+		{"/srv", "..file", "/srv/..file", ""},
+
+		{"/srv", "..", "", "outside of"},
+		{"/srv", "../etc/passwd", "", "outside of"},
+		{"/srv", "dir/../../etc/passwd", "", "outside of"},
+		{"srv", "../file", "", "outside of"},
+		{"/srv", "/../etc/passwd", "", "outside of"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			out, err := SafeJoin(tt.base, tt.userPath)
+			if !ztest.ErrorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nout:  %v\nwant: %v", err, tt.wantErr)
+			}
+			if out != tt.want {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	tmp, clean := ztest.TempFile(t, "data")
+	defer clean()
+
+	ch, stop, err := WatchFile(tmp, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wait := func(t *testing.T) {
+		t.Helper()
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				t.Fatal("channel closed")
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("no event")
+		}
+	}
+
+	err = ioutil.WriteFile(tmp, []byte("new data"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wait(t)
+
+	// Multiple changes before receiving are coalesced.
+	for i := 0; i < 3; i++ {
+		err = ioutil.WriteFile(tmp, []byte(strings.Repeat("x", i+1)), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(30 * time.Millisecond)
+	}
+	wait(t)
+	select {
+	case <-ch:
+		t.Fatal("changes not coalesced")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Removing and re-creating are also changes.
+	err = os.Remove(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wait(t)
+	err = ioutil.WriteFile(tmp, []byte("data"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wait(t)
+
+	stop()
+	stop() // Safe to call more than once.
+	if _, ok := <-ch; ok {
+		t.Fatal("channel not closed after stop")
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		_, _, err := WatchFile(tmp+"-nonexistent", time.Second)
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("wrong error: %v", err)
+		}
+		_, _, err = WatchFile(".", 0)
+		if !ztest.ErrorContains(err, "interval must be positive") {
+			t.Errorf("wrong error: %v", err)
+		}
+	})
+}
+
+func TestPermissionsFileMode(t *testing.T) {
+	tests := []os.FileMode{
+		0, 0400, 0644, 0755, 0777, 0600, 0070, 0007, 0124, 0421,
+		os.ModeSetuid | 0755,
+		os.ModeSetgid | 0750,
+		os.ModeSticky | 0777,
+		os.ModeSetuid | os.ModeSetgid | os.ModeSticky | 0777,
+		os.ModeSetuid | os.ModeSetgid | os.ModeSticky,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.String(), func(t *testing.T) {
+			out := ReadPermissions(tt).FileMode()
+			if out != tt {
+				t.Errorf("\nout:  %s\nwant: %s\n", out, tt)
+			}
+		})
+	}
+
+	t.Run("unix bits", func(t *testing.T) {
+		p := ReadPermissions(os.FileMode(Setuid | Setgid | Sticky | 0755))
+		if !p.Setuid || !p.Setgid || !p.Sticky {
+			t.Errorf("special bits not set: %#v", p)
+		}
+		want := os.ModeSetuid | os.ModeSetgid | os.ModeSticky | 0755
+		if out := p.FileMode(); out != want {
+			t.Errorf("\nout:  %s\nwant: %s\n", out, want)
+		}
+	})
+
+	t.Run("type bits", func(t *testing.T) {
+		out := ReadPermissions(os.ModeDir | 0755).FileMode()
+		if out != 0755 {
+			t.Errorf("\nout:  %s\nwant: %s\n", out, os.FileMode(0755))
+		}
+	})
+}
+
+func TestPermissionsString(t *testing.T) {
+	tests := []struct {
+		in         os.FileMode
+		sym, octal string
+	}{
+		{0, "---------", "0000"},
+		{0754, "rwxr-xr--", "0754"},
+		{0644, "rw-r--r--", "0644"},
+		{0777, "rwxrwxrwx", "0777"},
+		{0421, "r---w---x", "0421"},
+		{os.ModeSetuid | 0755, "rwsr-xr-x", "4755"},
+		{os.ModeSetuid | 0644, "rwSr--r--", "4644"},
+		{os.ModeSetgid | 0750, "rwxr-s---", "2750"},
+		{os.ModeSetgid | 0740, "rwxr-S---", "2740"},
+		{os.ModeSticky | 0777, "rwxrwxrwt", "1777"},
+		{os.ModeSticky | 0776, "rwxrwxrwT", "1776"},
+		{os.ModeSetuid | os.ModeSetgid | os.ModeSticky | 0777, "rwsrwsrwt", "7777"},
+		{os.ModeDir | 0755, "rwxr-xr-x", "0755"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in.String(), func(t *testing.T) {
+			p := ReadPermissions(tt.in)
+			if out := p.String(); out != tt.sym {
+				t.Errorf("String\nout:  %s\nwant: %s\n", out, tt.sym)
+			}
+			if out := p.Octal(); out != tt.octal {
+				t.Errorf("Octal\nout:  %s\nwant: %s\n", out, tt.octal)
+			}
+
+			if out, err := ParseSymbolic(tt.sym); err != nil || out != p {
+				t.Errorf("ParseSymbolic\nout:  %#v (%v)\nwant: %#v\n", out, err, p)
+			}
+			if out, err := ParseOctal(tt.octal); err != nil || out != p {
+				t.Errorf("ParseOctal\nout:  %#v (%v)\nwant: %#v\n", out, err, p)
+			}
+		})
+	}
+}
+
+func TestParsePermissions(t *testing.T) {
+	tests := []struct {
+		in      string
+		octal   bool
+		want    os.FileMode
+		wantErr string
+	}{
+		{"-rwxr-xr-x", false, 0755, ""},
+		{"drwxr-xr-x", false, 0755, ""},
+		{"lrwxrwxrwx", false, 0777, ""},
+		{"drwxrwxrwt", false, 0777 | os.ModeSticky, ""},
+		{"755", true, 0755, ""},
+		{"0755", true, 0755, ""},
+		{"00755", true, 0755, ""},
+		{"0o755", true, 0755, ""},
+		{"4755", true, 0755 | os.ModeSetuid, ""},
+		{"0", true, 0, ""},
+
+		{"", false, 0, "wrong length"},
+		{"rwxr-xr-", false, 0, "wrong length"},
+		{"rwxr-xr-x-", false, 0, "wrong length"},
+		{"xrwxr-xr-x", false, 0, "wrong length"},
+		{"rwxr-xr-xx", false, 0, "wrong length"},
+		{"rwxr-xr-q", false, 0, "invalid character"},
+		{"wrxr-xr-x", false, 0, "invalid character"},
+		{"rwtr-xr-x", false, 0, "invalid character"},
+		{"rwxr-xr-s", false, 0, "invalid character"},
+		{"RWXR-XR-X", false, 0, "invalid character"},
+
+		{"", true, 0, "wrong length"},
+		{"0o", true, 0, "wrong length"},
+		{"007555", true, 0, "wrong length"},
+		{"758", true, 0, "invalid octal"},
+		{"-755", true, 0, "invalid octal"},
+		{"0x755", true, 0, "invalid octal"},
+		{"rwx", true, 0, "invalid octal"},
+		{"17777", true, 0, "out of range"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			var (
+				out Permissions
+				err error
+			)
+			if tt.octal {
+				out, err = ParseOctal(tt.in)
+			} else {
+				out, err = ParseSymbolic(tt.in)
+			}
+			if !ztest.ErrorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nout:  %v\nwant: %v\n", err, tt.wantErr)
+			}
+			if want := ReadPermissions(tt.want); out != want {
+				t.Errorf("\nout:  %s\nwant: %s\n", out, want)
+			}
+		})
+	}
+}
+
+func TestGetenv(t *testing.T) {
+	const key = "ZOS_TEST_GETENV"
+	defer os.Unsetenv(key)
+
+	tests := []struct {
+		in       *string
+		str      string
+		i        int
+		b        bool
+		duration time.Duration
+	}{
+		{nil, "def", 42, true, time.Second},
+		{ztest.SP(""), "def", 42, true, time.Second},
+		{ztest.SP("invalid"), "invalid", 42, true, time.Second},
+		{ztest.SP("1"), "1", 1, true, time.Second},
+		{ztest.SP(" -5 "), " -5 ", -5, true, time.Second},
+		{ztest.SP("0"), "0", 0, false, 0},
+		{ztest.SP("FALSE"), "FALSE", 42, false, time.Second},
+		{ztest.SP("no"), "no", 42, false, time.Second},
+		{ztest.SP("Off"), "Off", 42, false, time.Second},
+		{ztest.SP("yes"), "yes", 42, true, time.Second},
+		{ztest.SP("1h5m"), "1h5m", 42, true, time.Hour + 5*time.Minute},
+		{ztest.SP("5"), "5", 5, true, time.Second},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			os.Unsetenv(key)
+			if tt.in != nil {
+				os.Setenv(key, *tt.in)
+			}
+
+			if out := GetenvDefault(key, "def"); out != tt.str {
+				t.Errorf("GetenvDefault: %#v; want %#v", out, tt.str)
+			}
+			if out := GetenvInt(key, 42); out != tt.i {
+				t.Errorf("GetenvInt: %#v; want %#v", out, tt.i)
+			}
+			if out := GetenvBool(key, true); out != tt.b {
+				t.Errorf("GetenvBool: %#v; want %#v", out, tt.b)
+			}
+			if out := GetenvDuration(key, time.Second); out != tt.duration {
+				t.Errorf("GetenvDuration: %#v; want %#v", out, tt.duration)
+			}
+		})
+	}
+}
+
+func TestArg(t *testing.T) {
+	defer func(a []string) { os.Args = a }(os.Args)
+
+	tests := []struct {
+		args []string
+		n    int
+		arg  string
+		def  string
+		all  []string
+	}{
+		{[]string{"prog"}, 0, "prog", "prog", nil},
+		{[]string{"prog"}, 1, "", "def", nil},
+		{[]string{"prog", "a", "b"}, 1, "a", "a", []string{"a", "b"}},
+		{[]string{"prog", "a", "b"}, 2, "b", "b", []string{"a", "b"}},
+		{[]string{"prog", "a", "b"}, 3, "", "def", []string{"a", "b"}},
+		{[]string{"prog", ""}, 1, "", "", []string{""}},
+		{nil, 0, "", "def", nil},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			os.Args = tt.args
+
+			if out := Arg(tt.n); out != tt.arg {
+				t.Errorf("Arg: %#v; want %#v", out, tt.arg)
+			}
+			if out := ArgDefault(tt.n, "def"); out != tt.def {
+				t.Errorf("ArgDefault: %#v; want %#v", out, tt.def)
+			}
+			if out := Args(); !reflect.DeepEqual(out, tt.all) {
+				t.Errorf("Args: %#v; want %#v", out, tt.all)
+			}
+		})
+	}
+}
+
+func TestWithWorkdir(t *testing.T) {
+	orig, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp, err := ioutil.TempDir("", "zos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	tmp, err = filepath.EvalSymlinks(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkRestored := func(t *testing.T) {
+		t.Helper()
+		wd, err := os.Getwd()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wd != orig {
+			t.Fatalf("working directory not restored: %q", wd)
+		}
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		var in string
+		err := WithWorkdir(tmp, func() error {
+			var err error
+			in, err = os.Getwd()
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if in != tmp {
+			t.Errorf("wrong working directory in fn: %q", in)
+		}
+		checkRestored(t)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		err := WithWorkdir(tmp, func() error { return errors.New("oh noes") })
+		if !ztest.ErrorContains(err, "oh noes") {
+			t.Errorf("wrong error: %v", err)
+		}
+		checkRestored(t)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		func() {
+			defer func() { recover() }()
+			WithWorkdir(tmp, func() error { panic("oh noes") })
+		}()
+		checkRestored(t)
+	})
+
+	t.Run("nonexistent", func(t *testing.T) {
+		called := false
+		err := WithWorkdir(filepath.Join(tmp, "nonexistent"), func() error {
+			called = true
+			return nil
+		})
+		if err == nil || called {
+			t.Errorf("called: %t; err: %v", called, err)
+		}
+		checkRestored(t)
+	})
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		mode  os.FileMode
+		umask uint32
+		want  os.FileMode
+	}{
+		{0777, 0, 0777},
+		{0777, 022, 0755},
+		{0666, 022, 0644},
+		{0666, 077, 0600},
+		{0644, 002, 0644},
+		{0777, 0777, 0},
+		{0777 | os.ModeDir, 022, 0755 | os.ModeDir},
+		{0755 | os.ModeSetuid, 077, 0700 | os.ModeSetuid},
+		{0755, 07022, 0755 &^ 022},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%#o/%#o", tt.mode, tt.umask), func(t *testing.T) {
+			out := Apply(tt.mode, tt.umask)
+			if out != tt.want {
+				t.Errorf("\nout:  %s\nwant: %s\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelfPath(t *testing.T) {
+	exe, err := SelfPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !filepath.IsAbs(exe) {
+		t.Errorf("not absolute: %q", exe)
+	}
+	if !IsFile(exe) {
+		t.Errorf("not a file: %q", exe)
+	}
+	if l, _ := filepath.EvalSymlinks(exe); l != exe {
+		t.Errorf("not resolved: %q -> %q", exe, l)
+	}
+
+	dir, err := ExecutableDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsDir(dir) {
+		t.Errorf("not a directory: %q", dir)
+	}
+	if filepath.Dir(exe) != dir {
+		t.Errorf("\nout:  %q\nwant: %q\n", dir, filepath.Dir(exe))
+	}
+}
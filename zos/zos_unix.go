@@ -17,8 +17,11 @@ func Readable(s os.FileInfo) (bool, error) {
 
 	perm := ReadPermissions(s.Mode())
 
-	if int(stat.Uid) == os.Geteuid() && perm.User.Read {
-		return true, nil
+	if int(stat.Uid) == os.Geteuid() {
+		return perm.User.Read, nil
+	}
+	if int(stat.Gid) == os.Getegid() {
+		return perm.Group.Read, nil
 	}
 
 	gids, err := os.Getgroups()
@@ -43,8 +46,11 @@ func Writable(s os.FileInfo) (bool, error) {
 
 	perm := ReadPermissions(s.Mode())
 
-	if int(stat.Uid) == os.Geteuid() && perm.User.Write {
-		return true, nil
+	if int(stat.Uid) == os.Geteuid() {
+		return perm.User.Write, nil
+	}
+	if int(stat.Gid) == os.Getegid() {
+		return perm.Group.Write, nil
 	}
 
 	gids, err := os.Getgroups()
@@ -53,9 +59,51 @@ func Writable(s os.FileInfo) (bool, error) {
 	}
 	for _, gid := range gids {
 		if int(stat.Gid) == gid {
-			return perm.Group.Read, nil
+			return perm.Group.Write, nil
 		}
 	}
 
 	return perm.Other.Write, nil
 }
+
+// IsReadable reports if the file at path is readable by the current user.
+//
+// This is always true for root, which can read any file regardless of the
+// permission bits.
+func IsReadable(path string) (bool, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("zos.IsReadable: %w", err)
+	}
+	if os.Geteuid() == 0 {
+		return true, nil
+	}
+	return Readable(st)
+}
+
+// IsWritable reports if the file at path is writable by the current user.
+//
+// This is always true for regular files and directories if the current user is
+// root, which can write to them regardless of the permission bits. It doesn't
+// check for read-only filesystems or immutable files.
+func IsWritable(path string) (bool, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("zos.IsWritable: %w", err)
+	}
+	if os.Geteuid() == 0 && (st.Mode().IsRegular() || st.IsDir()) {
+		return true, nil
+	}
+	return Writable(st)
+}
+
+// Umask returns the current umask.
+//
+// There is no way to read the umask without setting it, so this briefly sets
+// it to 0 and then restores it; files created by other goroutines in that
+// window may get the wrong permissions.
+func Umask() uint32 {
+	m := syscall.Umask(0)
+	syscall.Umask(m)
+	return uint32(m)
+}
@@ -0,0 +1,138 @@
+// +build aix darwin dragonfly freebsd js,wasm linux netbsd openbsd solaris
+
+package zos
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestIsReadableWritable(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "zos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	tests := []struct {
+		mode               os.FileMode
+		readable, writable bool
+	}{
+		{0600, true, true},
+		{0400, true, false},
+		{0200, false, true},
+		{0000, false, false},
+		{0066, false, false},
+		{0444, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			path := filepath.Join(tmp, tt.mode.String())
+			err := ioutil.WriteFile(path, nil, 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = os.Chmod(path, tt.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Root can read and write everything.
+			if os.Geteuid() == 0 {
+				tt.readable, tt.writable = true, true
+			}
+
+			r, err := IsReadable(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r != tt.readable {
+				t.Errorf("IsReadable: %t; want %t", r, tt.readable)
+			}
+
+			w, err := IsWritable(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if w != tt.writable {
+				t.Errorf("IsWritable: %t; want %t", w, tt.writable)
+			}
+		})
+	}
+
+	t.Run("open", func(t *testing.T) {
+		// Make sure it matches what the OS actually allows.
+		path := filepath.Join(tmp, "open")
+		err := ioutil.WriteFile(path, nil, 0400)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w, err := IsWritable(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fp, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err == nil {
+			fp.Close()
+		}
+		if w != (err == nil) {
+			t.Errorf("IsWritable: %t; but OpenFile error is %v", w, err)
+		}
+	})
+
+	t.Run("nonexistent", func(t *testing.T) {
+		_, err := IsReadable(filepath.Join(tmp, "nonexistent"))
+		if !os.IsNotExist(errors.Unwrap(err)) {
+			t.Errorf("wrong error: %v", err)
+		}
+		_, err = IsWritable(filepath.Join(tmp, "nonexistent"))
+		if !os.IsNotExist(errors.Unwrap(err)) {
+			t.Errorf("wrong error: %v", err)
+		}
+	})
+}
+
+func TestUmask(t *testing.T) {
+	old := syscall.Umask(027)
+	defer syscall.Umask(old)
+
+	if m := Umask(); m != 027 {
+		t.Fatalf("\nout:  %#o\nwant: %#o\n", m, 027)
+	}
+	// Make sure it was restored.
+	if m := Umask(); m != 027 {
+		t.Fatalf("not restored\nout:  %#o\nwant: %#o\n", m, 027)
+	}
+
+	tmp, err := ioutil.TempDir("", "zos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	for _, mode := range []os.FileMode{0777, 0666, 0644, 0600, 0751} {
+		t.Run(fmt.Sprintf("%#o", mode), func(t *testing.T) {
+			path := filepath.Join(tmp, fmt.Sprintf("%o", mode))
+			err := ioutil.WriteFile(path, nil, mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			st, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := Apply(mode, Umask())
+			if st.Mode().Perm() != want {
+				t.Errorf("\nout:  %s\nwant: %s\n", st.Mode().Perm(), want)
+			}
+		})
+	}
+}
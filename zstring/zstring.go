@@ -4,11 +4,20 @@
 package zstring
 
 import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"math/big"
 	"math/rand"
 	"sort"
 	"strings"
 	"time"
+	"unicode"
 	"unicode/utf8"
+
+	"zgo.at/zstd/zint"
 )
 
 // Reverse a string.
@@ -31,17 +40,60 @@ func Fields(s, sep string) []string {
 	}
 
 	f := strings.Split(s, sep)
-	var rm []int
+	n := 0
 	for i := range f {
-		f[i] = strings.TrimSpace(f[i])
-		if f[i] == "" {
-			rm = append(rm, i)
+		if e := strings.TrimSpace(f[i]); e != "" {
+			f[n] = e
+			n++
 		}
 	}
-	for _, i := range rm {
-		f = append(f[:i], f[i+1:]...)
+	return f[:n]
+}
+
+// FieldsN is like Fields, but returns at most n substrings; the last element
+// will be the unsplit remainder.
+//
+// e.g. "key: value: with: colons" with a sep of ":" and n of 2 results in
+// ["key", "value: with: colons"].
+//
+// Like strings.SplitN, n == 0 returns nil and n < 0 returns all substrings.
+func FieldsN(s, sep string, n int) []string {
+	if n == 0 {
+		return nil
+	}
+	if n < 0 {
+		return Fields(s, sep)
+	}
+
+	// Remove empty elements from the start and end, so that the remainder
+	// doesn't start or end with sep.
+	trim := func(s string) string {
+		for {
+			t := strings.TrimSpace(s)
+			if sep != "" {
+				t = strings.TrimSuffix(strings.TrimPrefix(t, sep), sep)
+			}
+			if t == s {
+				return s
+			}
+			s = t
+		}
+	}
+
+	var f []string
+	for {
+		s = trim(s)
+		if s == "" {
+			return f
+		}
+		if len(f) == n-1 {
+			return append(f, s)
+		}
+
+		var e string
+		e, s = Split2(s, sep)
+		f = append(f, strings.TrimSpace(e))
 	}
-	return f
 }
 
 // Sub returns a substring starting at start and ending at end.
@@ -80,6 +132,26 @@ func ElideLeft(s string, n int) string {
 	return s
 }
 
+// TruncateCount returns the "n" left characters of the string, and the number
+// of characters that were removed.
+//
+// Unlike ElideLeft no "…" is appended, so you can add your own message:
+//
+//   s, more := zstring.TruncateCount(s, 20)
+//   if more > 0 {
+//       s += fmt.Sprintf("… (%d more)", more)
+//   }
+func TruncateCount(s string, n int) (string, int) {
+	if n < 0 {
+		n = 0
+	}
+	ss := Sub(s, 0, n)
+	if len(s) != len(ss) {
+		return ss, utf8.RuneCountInString(s[len(ss):])
+	}
+	return s, 0
+}
+
 // ElideRight returns the "n" right characters of the string.
 //
 // If the string is shorter than "n" it will return the first "n" characters of
@@ -163,6 +235,25 @@ func Uniq(list []string) []string {
 	return l
 }
 
+// DedupAdjacent removes consecutive duplicate entries from list, like the uniq
+// command; unlike Uniq the order is preserved and non-adjacent duplicates are
+// kept.
+//
+// e.g. ["a", "a", "b", "a"] results in ["a", "b", "a"]. The list is not
+// modified.
+func DedupAdjacent(list []string) []string {
+	if list == nil {
+		return nil
+	}
+	l := make([]string, 0, len(list))
+	for i, str := range list {
+		if i == 0 || str != list[i-1] {
+			l = append(l, str)
+		}
+	}
+	return l
+}
+
 // Contains reports whether str is within the list.
 func Contains(list []string, str string) bool {
 	for _, item := range list {
@@ -173,6 +264,74 @@ func Contains(list []string, str string) bool {
 	return false
 }
 
+// ContainsFold reports whether str is within the list, ignoring case.
+func ContainsFold(list []string, str string) bool {
+	return IndexFold(list, str) > -1
+}
+
+// IndexFold returns the index of the first instance of str in the list,
+// ignoring case, or -1 if str is not present.
+func IndexFold(list []string, str string) int {
+	for i, item := range list {
+		if strings.EqualFold(item, str) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Chunk splits list into chunks of at most size elements; the last chunk will
+// have fewer elements if len(list) isn't a multiple of size.
+//
+// The chunks are sub-slices of list, but appending to a chunk will never
+// modify list. It returns a single chunk with all elements if size is 0 or
+// lower, and nil if the list is empty.
+func Chunk(list []string, size int) [][]string {
+	if len(list) == 0 {
+		return nil
+	}
+	if size <= 0 || size > len(list) {
+		size = len(list)
+	}
+
+	chunks := make([][]string, 0, (len(list)+size-1)/size)
+	for i := 0; i < len(list); i += size {
+		end := i + size
+		if end > len(list) {
+			end = len(list)
+		}
+		chunks = append(chunks, list[i:end:end])
+	}
+	return chunks
+}
+
+// ChunkRunes splits s in to strings of at most size characters; the last
+// string will be shorter if the length of s isn't a multiple of size.
+//
+// It returns s as the only element if size is 0 or lower, and nil if s is
+// empty.
+func ChunkRunes(s string, size int) []string {
+	if s == "" {
+		return nil
+	}
+	if size <= 0 {
+		return []string{s}
+	}
+
+	chunks := make([]string, 0, utf8.RuneCountInString(s)/size+1)
+	for s != "" {
+		i, n := 0, 0
+		for i < len(s) && n < size {
+			_, l := utf8.DecodeRuneInString(s[i:])
+			i += l
+			n++
+		}
+		chunks = append(chunks, s[:i])
+		s = s[i:]
+	}
+	return chunks
+}
+
 // Repeat returns a slice with the string s repeated n times.
 func Repeat(s string, n int) (r []string) {
 	for i := 0; i < n; i++ {
@@ -359,3 +518,1250 @@ func IndexPairs(str, start, end string) [][]int {
 	}
 	return r
 }
+
+// Columnize arranges items in aligned columns that fit in termWidth, similar to
+// what ls does.
+//
+// The number of columns is chosen based on the widest item; columns are
+// separated by two spaces and items are listed top-to-bottom first. Every line
+// ends with a newline. Items wider than termWidth are put on a line of their
+// own.
+func Columnize(items []string, termWidth int) string {
+	if len(items) == 0 {
+		return ""
+	}
+
+	const pad = 2
+	width := 0
+	for _, item := range items {
+		if l := utf8.RuneCountInString(item); l > width {
+			width = l
+		}
+	}
+
+	cols := (termWidth + pad) / (width + pad)
+	if cols < 1 {
+		cols = 1
+	}
+	rows := (len(items) + cols - 1) / cols
+	cols = (len(items) + rows - 1) / rows
+
+	var b strings.Builder
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			i := c*rows + r
+			if i >= len(items) {
+				break
+			}
+			if c == cols-1 || i+rows >= len(items) {
+				b.WriteString(items[i])
+				break
+			}
+			b.WriteString(AlignLeft(items[i], width+pad))
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+var boms = []string{
+	"\xef\xbb\xbf", // UTF-8
+	"\xfe\xff",     // UTF-16 BE
+	"\xff\xfe",     // UTF-16 LE
+}
+
+// HasBOM reports if s starts with a UTF-8 or UTF-16 byte order mark.
+func HasBOM(s string) bool {
+	for _, b := range boms {
+		if strings.HasPrefix(s, b) {
+			return true
+		}
+	}
+	return false
+}
+
+// TrimBOM removes a leading UTF-8 or UTF-16 byte order mark from s, if any.
+//
+// Note this only removes the BOM; UTF-16 data isn't converted to UTF-8.
+func TrimBOM(s string) string {
+	for _, b := range boms {
+		if strings.HasPrefix(s, b) {
+			return s[len(b):]
+		}
+	}
+	return s
+}
+
+// ShellQuote quotes s for safe use as a single argument in a POSIX shell.
+//
+// The string is wrapped in single quotes, and any single quotes inside it are
+// escaped as '\''. Strings that consist only of word characters ([a-zA-Z0-9_])
+// are returned as-is.
+func ShellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+
+	safe := true
+	for _, c := range s {
+		if !(c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// ShortHash returns a deterministic base62-encoded hash of s, truncated to
+// length characters.
+//
+// This uses 128-bit FNV-1a, and the maximum length is 22 characters. This is
+// useful for cache keys and such; it's not a cryptographic hash.
+func ShortHash(s string, length int) string {
+	const maxLen = 22
+	if length <= 0 {
+		return ""
+	}
+	if length > maxLen {
+		length = maxLen
+	}
+
+	h := fnv.New128a()
+	h.Write([]byte(s))
+	n := new(big.Int).SetBytes(h.Sum(nil))
+
+	var (
+		b    = make([]byte, maxLen)
+		mod  = new(big.Int)
+		base = big.NewInt(int64(len(base62)))
+	)
+	for i := len(b) - 1; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		b[i] = base62[mod.Int64()]
+	}
+	// Use the least significant digits: 2¹²⁸/62²¹ ≈ 7.8, so the most significant
+	// digit is only ever 0-7 and its values aren't evenly distributed. It's
+	// dropped unless the full 22 characters are requested.
+	return string(b[maxLen-length:])
+}
+
+// ParseQuery splits a search query into terms and phrases.
+//
+// Terms are separated by whitespace, and phrases are enclosed in double quotes
+// (which are not included in the return value). An unterminated quote is
+// treated as a phrase running until the end of the string. Empty phrases are
+// ignored.
+//
+// For example `foo "bar baz" qux` returns the terms ["foo", "qux"] and the
+// phrases ["bar baz"].
+func ParseQuery(s string) (terms []string, phrases []string) {
+	for {
+		i := strings.IndexByte(s, '"')
+		if i == -1 {
+			terms = append(terms, strings.Fields(s)...)
+			return terms, phrases
+		}
+		terms = append(terms, strings.Fields(s[:i])...)
+
+		s = s[i+1:]
+		e := strings.IndexByte(s, '"')
+		if e == -1 {
+			e = len(s)
+		}
+		if p := strings.TrimSpace(s[:e]); p != "" {
+			phrases = append(phrases, p)
+		}
+		if e == len(s) {
+			return terms, phrases
+		}
+		s = s[e+1:]
+	}
+}
+
+// IsASCII reports if s consists only of ASCII characters.
+func IsASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// ASCIIRatio returns the fraction of characters in s that are ASCII, from 0 to
+// 1. An empty string returns 1.
+func ASCIIRatio(s string) float64 {
+	var n, ascii int
+	for _, c := range s {
+		n++
+		if c < utf8.RuneSelf {
+			ascii++
+		}
+	}
+	if n == 0 {
+		return 1
+	}
+	return float64(ascii) / float64(n)
+}
+
+// SplitCamel splits a CamelCase or camelCase string into words.
+//
+// Runs of upper-case letters are kept together as acronyms, and digits are
+// added to the preceding word; for example "getHTTPResponseCode" becomes
+// ["get", "HTTP", "Response", "Code"] and "Base64Encode" becomes ["Base64",
+// "Encode"]. Any characters that are not letters or digits are treated as word
+// separators, and are not included.
+func SplitCamel(s string) []string {
+	var (
+		words []string
+		word  []rune
+		r     = []rune(s)
+	)
+	for i, c := range r {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			if len(word) > 0 {
+				words, word = append(words, string(word)), nil
+			}
+			continue
+		}
+
+		if len(word) > 0 && unicode.IsUpper(c) {
+			prev := r[i-1]
+			if !unicode.IsUpper(prev) ||
+				(i+1 < len(r) && unicode.IsLower(r[i+1])) {
+				words, word = append(words, string(word)), nil
+			}
+		}
+		word = append(word, c)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
+// GravatarHash gets the hash for an email address as used by Gravatar: the MD5
+// hex digest of the trimmed and lower-cased address.
+func GravatarHash(email string) string {
+	sum := md5.Sum([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
+
+// GravatarURL gets the Gravatar image URL for an email address.
+//
+// The size is the width and height of the image in pixels; it's omitted from
+// the URL (leaving it to Gravatar's default) if it's 0 or lower.
+func GravatarURL(email string, size int) string {
+	u := "https://www.gravatar.com/avatar/" + GravatarHash(email)
+	if size > 0 {
+		u += fmt.Sprintf("?s=%d", size)
+	}
+	return u
+}
+
+// TrimPrefixes removes the longest prefix from prefixes that s starts with.
+//
+// Only one prefix is removed; s is returned unchanged if none match.
+func TrimPrefixes(s string, prefixes ...string) string {
+	longest := -1
+	for _, p := range prefixes {
+		if len(p) > longest && strings.HasPrefix(s, p) {
+			longest = len(p)
+		}
+	}
+	if longest == -1 {
+		return s
+	}
+	return s[longest:]
+}
+
+// TrimSuffixes removes the longest suffix from suffixes that s ends with.
+//
+// Only one suffix is removed; s is returned unchanged if none match.
+func TrimSuffixes(s string, suffixes ...string) string {
+	longest := -1
+	for _, p := range suffixes {
+		if len(p) > longest && strings.HasSuffix(s, p) {
+			longest = len(p)
+		}
+	}
+	if longest == -1 {
+		return s
+	}
+	return s[:len(s)-longest]
+}
+
+// TrimPrefixFold returns s without the provided leading prefix, ignoring case.
+// It returns s unchanged if it doesn't start with prefix.
+func TrimPrefixFold(s, prefix string) string {
+	n := utf8.RuneCountInString(prefix)
+	i := 0
+	for ; n > 0 && i < len(s); n-- {
+		_, l := utf8.DecodeRuneInString(s[i:])
+		i += l
+	}
+	if n == 0 && strings.EqualFold(s[:i], prefix) {
+		return s[i:]
+	}
+	return s
+}
+
+// TrimSuffixFold returns s without the provided trailing suffix, ignoring case.
+// It returns s unchanged if it doesn't end with suffix.
+func TrimSuffixFold(s, suffix string) string {
+	n := utf8.RuneCountInString(suffix)
+	i := len(s)
+	for ; n > 0 && i > 0; n-- {
+		_, l := utf8.DecodeLastRuneInString(s[:i])
+		i -= l
+	}
+	if n == 0 && strings.EqualFold(s[i:], suffix) {
+		return s[:i]
+	}
+	return s
+}
+
+// Indent adds prefix to the start of every line in s, except lines consisting
+// only of whitespace, which are left as-is (like Python's textwrap.indent).
+//
+// Both "\n" and "\r\n" line endings are recognized, and are preserved as-is.
+func Indent(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if strings.TrimSpace(l) != "" {
+			lines[i] = prefix + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Dedent removes any common leading whitespace from every line in s.
+//
+// This is similar to Python's textwrap.dedent: lines consisting only of
+// whitespace are ignored when finding the common whitespace, and are
+// normalized to empty lines. Tabs and spaces are not treated as equal, so
+// "\t  a" and "  b" have no common leading whitespace.
+//
+// Both "\n" and "\r\n" line endings are recognized, and are preserved as-is.
+func Dedent(s string) string {
+	lines := strings.Split(s, "\n")
+
+	var (
+		common string
+		first  = true
+	)
+	for _, l := range lines {
+		l = strings.TrimSuffix(l, "\r")
+		trimmed := strings.TrimLeft(l, " \t")
+		if trimmed == "" {
+			continue
+		}
+		ws := l[:len(l)-len(trimmed)]
+		if first {
+			common, first = ws, false
+			continue
+		}
+		i := 0
+		for i < len(common) && i < len(ws) && common[i] == ws[i] {
+			i++
+		}
+		common = common[:i]
+	}
+
+	for i, l := range lines {
+		body := strings.TrimSuffix(l, "\r")
+		cr := l[len(body):]
+		if strings.TrimLeft(body, " \t") == "" {
+			lines[i] = cr
+			continue
+		}
+		lines[i] = body[len(common):] + cr
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Excerpt returns the text around the first case-insensitive match of term,
+// with up to radius characters of context on both sides.
+//
+// "…" is added to the start and/or end if the text was truncated. The first
+// 2*radius characters are returned (as with ElideLeft) if there is no match.
+func Excerpt(text, term string, radius int) string {
+	var (
+		r     = []rune(text)
+		n     = utf8.RuneCountInString(term)
+		start = -1
+	)
+	if n > 0 {
+		start = indexFold(r, term, 0)
+	}
+	if start == -1 {
+		return ElideLeft(text, 2*radius)
+	}
+
+	from, to := start-radius, start+n+radius
+	var pre, post string
+	if from <= 0 {
+		from = 0
+	} else {
+		pre = "…"
+	}
+	if to >= len(r) {
+		to = len(r)
+	} else {
+		post = "…"
+	}
+	return pre + string(r[from:to]) + post
+}
+
+// Initials returns the upper-cased first letter of every word in s, up to max
+// letters; for example "John Ronald Reuel" becomes "JRR".
+//
+// Leading punctuation in a word is skipped, and every Han, Hiragana, Katakana,
+// or Hangul character is considered to be a word on its own. A max of 0 or
+// lower means there is no limit.
+func Initials(s string, max int) string {
+	var b strings.Builder
+	n := 0
+	add := func(r rune) bool {
+		b.WriteRune(unicode.ToUpper(r))
+		n++
+		return max > 0 && n >= max
+	}
+
+	for _, w := range strings.Fields(s) {
+		first := true
+		for _, r := range w {
+			if isIdeographic(r) {
+				if add(r) {
+					return b.String()
+				}
+				first = true
+				continue
+			}
+			if first && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				if add(r) {
+					return b.String()
+				}
+				first = false
+			}
+		}
+	}
+	return b.String()
+}
+
+func isIdeographic(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// CountFold counts the number of instances of substr in s, ignoring case.
+//
+// Overlapping matches are counted if overlap is true; for example "aaa"
+// contains "aa" once without overlap, and twice with overlap.
+//
+// If substr is empty it returns 1 + the number of characters in s, just like
+// strings.Count.
+func CountFold(s, substr string, overlap bool) int {
+	n := utf8.RuneCountInString(substr)
+	if n == 0 {
+		return utf8.RuneCountInString(s) + 1
+	}
+
+	var (
+		r     = []rune(s)
+		count int
+	)
+	for i := indexFold(r, substr, 0); i > -1; {
+		count++
+		if overlap {
+			i = indexFold(r, substr, i+1)
+		} else {
+			i = indexFold(r, substr, i+n)
+		}
+	}
+	return count
+}
+
+// indexFold returns the index of the first case-insensitive match of term in r
+// at or after from, or -1 if there is no match.
+//
+// This is the same as comparing every window with strings.EqualFold, but
+// without converting them to a string.
+func indexFold(r []rune, term string, from int) int {
+	if from < 0 {
+		from = 0
+	}
+outer:
+	for i := from; i < len(r); i++ {
+		j := i
+		for _, tr := range term {
+			if j >= len(r) || !equalFoldRune(r[j], tr) {
+				continue outer
+			}
+			j++
+		}
+		return i
+	}
+	return -1
+}
+
+// equalFoldRune reports if a and b are equal under simple Unicode case folding,
+// like strings.EqualFold does for every rune.
+func equalFoldRune(a, b rune) bool {
+	if a == b {
+		return true
+	}
+	if a > b {
+		a, b = b, a
+	}
+	if b < utf8.RuneSelf {
+		return 'A' <= a && a <= 'Z' && b == a+'a'-'A'
+	}
+	f := unicode.SimpleFold(a)
+	for f != a && f < b {
+		f = unicode.SimpleFold(f)
+	}
+	return f == b
+}
+
+// Surround returns s with left prepended and right appended.
+func Surround(s, left, right string) string {
+	return left + s + right
+}
+
+// Quote wraps s in double quotes, escaping any double quotes and backslashes
+// inside it with a backslash.
+//
+// Unlike strconv.Quote this doesn't escape non-ASCII or non-printable
+// characters, which makes it more suitable for messages meant for humans.
+func Quote(s string) string {
+	if strings.ContainsAny(s, `"\`) {
+		s = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+	}
+	return `"` + s + `"`
+}
+
+// QuoteList quotes every element with Quote and joins them with ", ".
+//
+// e.g. ["a", "b c"] results in `"a", "b c"`.
+func QuoteList(list []string) string {
+	q := make([]string, 0, len(list))
+	for _, s := range list {
+		q = append(q, Quote(s))
+	}
+	return strings.Join(q, ", ")
+}
+
+// Common abbreviations that don't end a sentence, for CapitalizeSentences.
+var abbreviations = map[string]struct{}{
+	"e.g": {}, "i.e": {}, "vs": {}, "mr": {}, "mrs": {}, "ms": {}, "dr": {},
+	"prof": {}, "st": {}, "jr": {}, "sr": {}, "approx": {}, "cf": {},
+}
+
+// CapitalizeSentences transforms the first letter of every sentence to upper
+// case, leaving the rest of the casing alone.
+//
+// A sentence starts at the start of the string, and after a ".", "!", or "?"
+// that's followed by whitespace (optionally after a closing quote or
+// parenthesis). A "." after a few common abbreviations such as "e.g." or "Dr."
+// doesn't end a sentence.
+func CapitalizeSentences(s string) string {
+	var (
+		r     = []rune(s)
+		start = true
+	)
+	for i := 0; i < len(r); i++ {
+		c := r[i]
+		if start {
+			if unicode.IsLetter(c) {
+				r[i] = unicode.ToUpper(c)
+				start = false
+			} else if unicode.IsDigit(c) {
+				start = false
+			}
+		}
+		if c != '.' && c != '!' && c != '?' {
+			continue
+		}
+
+		j := i + 1
+		for j < len(r) && strings.ContainsRune(`.!?"')]’”»`, r[j]) {
+			j++
+		}
+		if j < len(r) && !unicode.IsSpace(r[j]) {
+			continue
+		}
+		if c == '.' {
+			w := i
+			for w > 0 && (unicode.IsLetter(r[w-1]) || r[w-1] == '.') {
+				w--
+			}
+			if _, ok := abbreviations[strings.ToLower(string(r[w:i]))]; ok {
+				continue
+			}
+		}
+		start = true
+		i = j - 1
+	}
+	return string(r)
+}
+
+// Tokenize splits s on whitespace, keeping quoted strings together, similar to
+// how a POSIX shell splits arguments.
+//
+// Text inside single quotes is kept as-is. Inside double quotes a backslash can
+// be used to escape a double quote or backslash, and outside of quotes a
+// backslash escapes any character. The quotes themselves are removed, so
+// `a "b c" 'd'` becomes ["a", "b c", "d"].
+//
+// An error is returned on an unterminated quote or trailing backslash.
+func Tokenize(s string) ([]string, error) {
+	var (
+		tokens  []string
+		b       strings.Builder
+		inToken bool
+		quote   rune
+		escaped bool
+	)
+	for _, c := range s {
+		switch {
+		case escaped:
+			if quote == '"' && c != '"' && c != '\\' {
+				b.WriteRune('\\')
+			}
+			b.WriteRune(c)
+			escaped = false
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				b.WriteRune(c)
+			}
+		case c == '\\':
+			escaped, inToken = true, true
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			} else {
+				b.WriteRune(c)
+			}
+		case c == '"' || c == '\'':
+			quote, inToken = c, true
+		case unicode.IsSpace(c):
+			if inToken {
+				tokens = append(tokens, b.String())
+				b.Reset()
+				inToken = false
+			}
+		default:
+			b.WriteRune(c)
+			inToken = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("zstring.Tokenize: unterminated %c quote in %q", quote, s)
+	}
+	if escaped {
+		return nil, fmt.Errorf("zstring.Tokenize: trailing backslash in %q", s)
+	}
+	if inToken {
+		tokens = append(tokens, b.String())
+	}
+	return tokens, nil
+}
+
+// Ordinal returns the English ordinal for n, e.g. "1st", "2nd", "3rd", "11th".
+func Ordinal(n int) string {
+	abs := n
+	if abs < 0 {
+		abs = -abs
+	}
+	suffix := "th"
+	if abs%100 < 11 || abs%100 > 13 {
+		switch abs % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// Irregular plurals for Pluralize. Keys and values should be lower case.
+//
+// This can be modified to add or override words.
+var Irregular = map[string]string{
+	"child": "children", "person": "people", "man": "men", "woman": "women",
+	"mouse": "mice", "goose": "geese", "foot": "feet", "tooth": "teeth",
+	"ox": "oxen", "die": "dice", "leaf": "leaves", "life": "lives",
+	"knife": "knives", "wife": "wives", "half": "halves", "wolf": "wolves",
+	"shelf": "shelves", "hero": "heroes", "potato": "potatoes",
+	"tomato": "tomatoes", "criterion": "criteria", "index": "indices",
+	"matrix": "matrices", "analysis": "analyses", "crisis": "crises",
+	"sheep": "sheep", "fish": "fish", "deer": "deer", "series": "series",
+	"species": "species", "news": "news",
+}
+
+// Pluralize returns the English plural form of word, unless n is 1 or -1.
+//
+// Words in Irregular are used as-is; otherwise "es" is added to words ending in
+// s, x, z, ch, or sh; a consonant followed by "y" becomes "ies"; and "s" is
+// added to everything else.
+//
+// The casing of the word is kept, and the suffix is upper case if the last
+// letter is: "iPhone" becomes "iPhones" and "URL" becomes "URLS". Irregular
+// plurals are upper cased if the word is all upper case, or start with an upper
+// case letter if the word does.
+func Pluralize(word string, n int) string {
+	if n == 1 || n == -1 || word == "" {
+		return word
+	}
+
+	lower := strings.ToLower(word)
+	if p, ok := Irregular[lower]; ok {
+		switch {
+		case word == strings.ToUpper(word) && word != lower:
+			return strings.ToUpper(p)
+		case word != lower:
+			return UpperFirst(p)
+		}
+		return p
+	}
+
+	last, _ := utf8.DecodeLastRuneInString(word)
+	suffix := func(s string) string {
+		if unicode.IsUpper(last) {
+			return strings.ToUpper(s)
+		}
+		return s
+	}
+	switch {
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"),
+		strings.HasSuffix(lower, "z"), strings.HasSuffix(lower, "ch"),
+		strings.HasSuffix(lower, "sh"):
+		return word + suffix("es")
+	case len(lower) > 1 && lower[len(lower)-1] == 'y' &&
+		!strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return word[:len(word)-1] + suffix("ies")
+	}
+	return word + suffix("s")
+}
+
+// Mask replaces all characters in s with maskRune, except for the first
+// keepStart and last keepEnd characters.
+//
+// For example Mask("4111111111111111", 0, 4, '*') returns "************1111".
+// All characters are masked if s has keepStart+keepEnd characters or fewer, so
+// short strings are never revealed in full.
+func Mask(s string, keepStart, keepEnd int, maskRune rune) string {
+	if keepStart < 0 {
+		keepStart = 0
+	}
+	if keepEnd < 0 {
+		keepEnd = 0
+	}
+
+	r := []rune(s)
+	if len(r) <= keepStart+keepEnd {
+		keepStart, keepEnd = 0, 0
+	}
+	for i := keepStart; i < len(r)-keepEnd; i++ {
+		r[i] = maskRune
+	}
+	return string(r)
+}
+
+// MaskEmail masks the local part of an email address with "*", except for the
+// first character; for example "martin@example.com" becomes
+// "m*****@example.com".
+//
+// The entire string is masked in the same way if it doesn't contain an "@".
+func MaskEmail(email string) string {
+	i := strings.LastIndexByte(email, '@')
+	if i == -1 {
+		return Mask(email, 1, 0, '*')
+	}
+	return Mask(email[:i], 1, 0, '*') + email[i:]
+}
+
+// NaturalLess reports whether a sorts before b in "natural" order, where runs
+// of digits are compared by their numeric value, so that "file2" sorts before
+// "file10".
+//
+// Numbers that are equal except for leading zeros sort with the fewest zeros
+// first ("1" before "01"). Everything else is compared by Unicode code point.
+func NaturalLess(a, b string) bool {
+	isDigit := func(c byte) bool { return c >= '0' && c <= '9' }
+
+	zeros := 0
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if !isDigit(a[i]) || !isDigit(b[j]) {
+			ra, na := utf8.DecodeRuneInString(a[i:])
+			rb, nb := utf8.DecodeRuneInString(b[j:])
+			if ra != rb {
+				return ra < rb
+			}
+			i, j = i+na, j+nb
+			continue
+		}
+
+		// Compare the numbers without leading zeros: a longer number is
+		// always larger, and if they're the same length the first
+		// different digit decides.
+		si, sj := i, j
+		for si < len(a) && a[si] == '0' {
+			si++
+		}
+		for sj < len(b) && b[sj] == '0' {
+			sj++
+		}
+		ei, ej := si, sj
+		for ei < len(a) && isDigit(a[ei]) {
+			ei++
+		}
+		for ej < len(b) && isDigit(b[ej]) {
+			ej++
+		}
+		if ei-si != ej-sj {
+			return ei-si < ej-sj
+		}
+		if na, nb := a[si:ei], b[sj:ej]; na != nb {
+			return na < nb
+		}
+		if zeros == 0 {
+			zeros = (si - i) - (sj - j)
+		}
+		i, j = ei, ej
+	}
+
+	if len(a)-i != len(b)-j {
+		return len(a)-i < len(b)-j
+	}
+	if zeros != 0 {
+		return zeros < 0
+	}
+	return a < b
+}
+
+// SortNatural sorts the list in "natural" order, modifying it in place.
+//
+// See NaturalLess for details on the ordering.
+func SortNatural(list []string) {
+	sort.Slice(list, func(i, j int) bool { return NaturalLess(list[i], list[j]) })
+}
+
+// ExpandRange expands a comma-separated list of numbers and ranges; for
+// example "1-3,5,7-8" results in [1 2 3 5 7 8].
+//
+// The numbers are returned in the order they appear in, unless sortUniq is
+// true, in which case the result is sorted and duplicates are removed. Ranges
+// are parsed with zint.ParseRange(); it's an error if the start of a range is
+// higher than the end (e.g. "8-5"), or if the list expands to more than
+// zint.MaxRange values.
+//
+// Also see zint.ParseList(), which does the same for int64 values.
+func ExpandRange(s string, sortUniq bool) ([]int, error) {
+	var (
+		ret   []int
+		total uint64
+	)
+	for _, item := range strings.Split(s, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+
+		s, e, err := zint.ParseRange(item)
+		if err != nil {
+			return nil, fmt.Errorf("zstring.ExpandRange: %w", err)
+		}
+		if int64(int(s)) != s || int64(int(e)) != e {
+			return nil, fmt.Errorf("zstring.ExpandRange: %q out of range for int", item)
+		}
+		total += uint64(e) - uint64(s) + 1
+		if total > uint64(zint.MaxRange) {
+			return nil, fmt.Errorf("zstring.ExpandRange: list has more than %d values", zint.MaxRange)
+		}
+		ret = append(ret, zint.Range(int(s), int(e))...)
+	}
+
+	if sortUniq && len(ret) > 0 {
+		sort.Ints(ret)
+		u := ret[:1]
+		for _, n := range ret[1:] {
+			if n != u[len(u)-1] {
+				u = append(u, n)
+			}
+		}
+		ret = u
+	}
+	return ret, nil
+}
+
+// CommonPrefix returns the longest prefix that all strings in list start with.
+//
+// It never returns a partial UTF-8 character, and returns an empty string if
+// list is empty.
+func CommonPrefix(list []string) string {
+	if len(list) == 0 {
+		return ""
+	}
+	prefix := list[0]
+	for _, s := range list[1:] {
+		i := 0
+		for i < len(prefix) && i < len(s) && prefix[i] == s[i] {
+			i++
+		}
+		for i > 0 && i < len(prefix) && !utf8.RuneStart(prefix[i]) {
+			i--
+		}
+		prefix = prefix[:i]
+	}
+	return prefix
+}
+
+// CommonSuffix returns the longest suffix that all strings in list end with.
+//
+// It never returns a partial UTF-8 character, and returns an empty string if
+// list is empty.
+func CommonSuffix(list []string) string {
+	if len(list) == 0 {
+		return ""
+	}
+	suffix := list[0]
+	for _, s := range list[1:] {
+		i := 0
+		for i < len(suffix) && i < len(s) && suffix[len(suffix)-1-i] == s[len(s)-1-i] {
+			i++
+		}
+		for i > 0 && !utf8.RuneStart(suffix[len(suffix)-i]) {
+			i--
+		}
+		suffix = suffix[len(suffix)-i:]
+	}
+	return suffix
+}
+
+// Between returns the text between the first occurrence of start and the first
+// occurrence of end after that, or an empty string if either isn't found.
+//
+// Delimiters aren't balanced: Between("((a))", "(", ")") returns "(a".
+func Between(s, start, end string) string {
+	b, _ := between(s, start, end)
+	return b
+}
+
+// AllBetween returns the text between all occurrences of start and end, or nil
+// if there are none.
+//
+// Matches don't overlap; searching for the next start begins after the
+// previous end.
+func AllBetween(s, start, end string) []string {
+	var all []string
+	for {
+		b, i := between(s, start, end)
+		if i <= 0 { // 0 if both start and end are empty.
+			return all
+		}
+		all = append(all, b)
+		s = s[i:]
+	}
+}
+
+// between returns the text between start and end, and the index in s right
+// after end, or -1 if there is no match.
+func between(s, start, end string) (string, int) {
+	i := strings.Index(s, start)
+	if i == -1 {
+		return "", -1
+	}
+	i += len(start)
+	j := strings.Index(s[i:], end)
+	if j == -1 {
+		return "", -1
+	}
+	return s[i : i+j], i + j + len(end)
+}
+
+var voidElements = map[string]struct{}{
+	"area": {}, "base": {}, "br": {}, "col": {}, "embed": {}, "hr": {}, "img": {},
+	"input": {}, "link": {}, "meta": {}, "param": {}, "source": {}, "track": {},
+	"wbr": {},
+}
+
+// LeftHTML returns the first n visible characters of the HTML string s.
+//
+// This works like ElideLeft(), except that tags aren't counted and are never
+// split, and entities such as "&amp;" count as one character. Any tags that
+// are still open at the cut point are closed, and "…" is appended before the
+// closing tags if the text was truncated.
+//
+// This assumes s is reasonably well-formed HTML; it doesn't sanitize anything.
+func LeftHTML(s string, n int) template.HTML {
+	var (
+		open    []string
+		visible int
+		i       int
+	)
+	for i < len(s) {
+		switch s[i] {
+		case '<':
+			if strings.HasPrefix(s[i:], "<!--") {
+				e := strings.Index(s[i:], "-->")
+				if e == -1 {
+					i = len(s)
+				} else {
+					i += e + 3
+				}
+				continue
+			}
+
+			e := tagEnd(s[i:])
+			if e == -1 { // Not a tag.
+				break
+			}
+			tag := s[i+1 : i+e]
+			i += e + 1
+
+			closing := strings.HasPrefix(tag, "/")
+			name := strings.TrimPrefix(tag, "/")
+			if x := strings.IndexAny(name, " \t\n\r/"); x > -1 {
+				name = name[:x]
+			}
+			name = strings.ToLower(name)
+			if _, ok := voidElements[name]; ok || name == "" || name[0] == '!' || strings.HasSuffix(tag, "/") {
+				continue
+			}
+
+			if !closing {
+				open = append(open, name)
+				continue
+			}
+			for j := len(open) - 1; j >= 0; j-- {
+				if open[j] == name {
+					open = open[:j]
+					break
+				}
+			}
+			continue
+		}
+
+		if visible == n {
+			var b strings.Builder
+			b.WriteString(s[:i])
+			b.WriteString("…")
+			for j := len(open) - 1; j >= 0; j-- {
+				b.WriteString("</" + open[j] + ">")
+			}
+			return template.HTML(b.String())
+		}
+
+		visible++
+		if s[i] == '&' {
+			if e := strings.IndexByte(s[i:], ';'); e > 1 && e < 33 && !strings.ContainsAny(s[i+1:i+e], " \t\n<&") {
+				i += e + 1
+				continue
+			}
+		}
+		_, l := utf8.DecodeRuneInString(s[i:])
+		i += l
+	}
+	return template.HTML(s)
+}
+
+// tagEnd finds the index of the ">" that ends the tag at the start of s,
+// ignoring any ">" inside quoted attribute values.
+func tagEnd(s string) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i
+		}
+	}
+	return -1
+}
+
+// CountWords counts the number of words in s.
+//
+// A word is a run of letters, digits, and marks. An apostrophe or hyphen
+// between two letters doesn't end a word, so "don't" and "well-known" are one
+// word, and neither does a "." or "," inside a number such as "4.99".
+//
+// Every Han, Hiragana, or Katakana character is counted as a word, since
+// Chinese and Japanese don't use spaces between words.
+func CountWords(s string) int {
+	var (
+		r      = []rune(s)
+		n      int
+		inWord bool
+	)
+	for i, c := range r {
+		switch {
+		case unicode.In(c, unicode.Han, unicode.Hiragana, unicode.Katakana):
+			n++
+			inWord = false
+		case unicode.IsLetter(c) || unicode.IsDigit(c) || unicode.IsMark(c):
+			if !inWord {
+				n++
+				inWord = true
+			}
+		case inWord && (c == '\'' || c == '’' || c == '-') &&
+			i+1 < len(r) && (unicode.IsLetter(r[i+1]) || unicode.IsDigit(r[i+1])):
+			// Part of the word.
+		case inWord && (c == '.' || c == ',') && unicode.IsDigit(r[i-1]) &&
+			i+1 < len(r) && unicode.IsDigit(r[i+1]):
+			// Decimal or thousands separator in a number.
+		default:
+			inWord = false
+		}
+	}
+	return n
+}
+
+// ReadingTime estimates how long it takes to read s at wpm words per minute,
+// rounded to the nearest second. A wpm of 0 or lower defaults to 200.
+func ReadingTime(s string, wpm int) time.Duration {
+	if wpm <= 0 {
+		wpm = 200
+	}
+	d := time.Duration(CountWords(s)) * time.Minute / time.Duration(wpm)
+	return d.Round(time.Second)
+}
+
+// SplitKV splits s in to key/value pairs; for example "a=1, b=2" with a
+// pairSep of "," and kvSep of "=" results in {"a": "1", "b": "2"}.
+//
+// Pairs are split with Fields(), so leading/trailing whitespace and empty pairs
+// are removed. Whitespace around keys and values is also removed. The last
+// value is used if a key appears more than once. It's an error if a pair
+// doesn't contain kvSep or if the key is empty; the value can be empty.
+func SplitKV(s, pairSep, kvSep string) (map[string]string, error) {
+	pairs, err := splitKV(s, pairSep, kvSep)
+	if err != nil {
+		return nil, fmt.Errorf("zstring.SplitKV: %w", err)
+	}
+	m := make(map[string]string, len(pairs))
+	for _, p := range pairs {
+		m[p[0]] = p[1]
+	}
+	return m, nil
+}
+
+// SplitKVOrdered is like SplitKV, but returns the key/value pairs in the order
+// they appear in. Duplicate keys are kept.
+func SplitKVOrdered(s, pairSep, kvSep string) ([][2]string, error) {
+	pairs, err := splitKV(s, pairSep, kvSep)
+	if err != nil {
+		return nil, fmt.Errorf("zstring.SplitKVOrdered: %w", err)
+	}
+	return pairs, nil
+}
+
+func splitKV(s, pairSep, kvSep string) ([][2]string, error) {
+	fields := Fields(s, pairSep)
+	pairs := make([][2]string, 0, len(fields))
+	for _, f := range fields {
+		i := strings.Index(f, kvSep)
+		if i == -1 {
+			return nil, fmt.Errorf("no %q in %q", kvSep, f)
+		}
+		k, v := strings.TrimSpace(f[:i]), strings.TrimSpace(f[i+len(kvSep):])
+		if k == "" {
+			return nil, fmt.Errorf("empty key in %q", f)
+		}
+		pairs = append(pairs, [2]string{k, v})
+	}
+	return pairs, nil
+}
+
+// StripZeroWidth removes all zero-width characters from s: zero-width space
+// (U+200B), zero-width non-joiner (U+200C), zero-width joiner (U+200D), word
+// joiner (U+2060), and zero-width no-break space/byte order mark (U+FEFF).
+//
+// Note that this breaks emoji sequences that use the zero-width joiner, such
+// as some family and profession emojis.
+func StripZeroWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '\u200b', '\u200c', '\u200d', '\u2060', '\ufeff':
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// Cyrillic and Greek letters that look (nearly) identical to Latin letters.
+const lookalikes = "аеорсухіјѕԁһӏАВЕКМНОРСТХІЈЅ" + "ανοιρτυχΑΒΕΖΗΙΚΜΝΟΡΤΥΧ"
+
+// HasHomoglyphs reports if s looks like it may be trying to spoof a Latin
+// string with letters from other scripts, for example "pаypal" with a Cyrillic
+// "а".
+//
+// This is a simple heuristic: it returns true if s mixes Latin letters with
+// Cyrillic or Greek letters, or if s has no Latin letters but all its Cyrillic
+// and Greek letters look like Latin ones (e.g. "раураl" in Cyrillic). Other
+// characters, such as digits, punctuation, and other scripts, are ignored.
+func HasHomoglyphs(s string) bool {
+	var latin, other, allLookalike = false, false, true
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin = true
+		case unicode.In(r, unicode.Cyrillic, unicode.Greek):
+			other = true
+			if !strings.ContainsRune(lookalikes, r) {
+				allLookalike = false
+			}
+		}
+	}
+	if latin {
+		return other
+	}
+	return other && allLookalike
+}
+
+// Highlight wraps every occurrence of term in text with left and right,
+// ignoring case; for example Highlight("Go go", "go", "<mark>", "</mark>")
+// returns "<mark>Go</mark> <mark>go</mark>".
+//
+// The original casing of the matched text is kept, and matches don't overlap.
+// The text is returned as-is if term is empty.
+func Highlight(text, term, left, right string) string {
+	n := utf8.RuneCountInString(term)
+	if n == 0 {
+		return text
+	}
+
+	var (
+		r    = []rune(text)
+		b    strings.Builder
+		last = 0
+	)
+	for i := indexFold(r, term, 0); i > -1; i = indexFold(r, term, last) {
+		b.WriteString(string(r[last:i]))
+		b.WriteString(left)
+		b.WriteString(string(r[i : i+n]))
+		b.WriteString(right)
+		last = i + n
+	}
+	if last == 0 {
+		return text
+	}
+	b.WriteString(string(r[last:]))
+	return b.String()
+}
@@ -2,9 +2,11 @@ package zstring
 
 import (
 	"fmt"
+	"html/template"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"zgo.at/zstd/ztest"
 )
@@ -52,6 +54,9 @@ func TestFields(t *testing.T) {
 		{"a;b", []string{"a", "b"}},
 		{"  a  ;  b  ", []string{"a", "b"}},
 		{"  a  ;  b  ; ", []string{"a", "b"}},
+		{"a;;;b", []string{"a", "b"}},
+		{";a; ;;b;;c;", []string{"a", "b", "c"}},
+		{" ; ; ", []string{}},
 	}
 
 	for _, tt := range tests {
@@ -526,3 +531,1469 @@ func TestSplit(t *testing.T) {
 		})
 	}
 }
+
+func TestColumnize(t *testing.T) {
+	tests := []struct {
+		in    []string
+		width int
+		want  string
+	}{
+		{nil, 80, ""},
+		{[]string{"a", "b", "c"}, 80, "a  b  c\n"},
+		{[]string{"one", "two", "three"}, 80, "one    two    three\n"},
+		{[]string{"a", "b", "c", "d", "e"}, 7, "a  c  e\nb  d\n"},
+		{[]string{"a", "b", "c", "d"}, 4, "a  c\nb  d\n"},
+		{[]string{"a", "b", "c"}, 1, "a\nb\nc\n"},
+		{[]string{"汉语", "漢語", "ab"}, 8, "汉语  ab\n漢語\n"},
+		{[]string{"much too long"}, 5, "much too long\n"},
+		{[]string{"a", "much too long"}, 5, "a\nmuch too long\n"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			out := Columnize(tt.in, tt.width)
+			if out != tt.want {
+				t.Errorf("\nout:  %q\nwant: %q\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestBOM(t *testing.T) {
+	tests := []struct {
+		in, want string
+		has      bool
+	}{
+		{"", "", false},
+		{"hello", "hello", false},
+		{"\ufeffhello", "hello", true},
+		{"\xef\xbb\xbf", "", true},
+		{"\xfe\xffh\x00", "h\x00", true},
+		{"\xff\xfe\x00h", "\x00h", true},
+		{"hello\ufeff", "hello\ufeff", false},
+		{"\xef\xbbhello", "\xef\xbbhello", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			has := HasBOM(tt.in)
+			if has != tt.has {
+				t.Errorf("HasBOM\nout:  %#v\nwant: %#v\n", has, tt.has)
+			}
+			out := TrimBOM(tt.in)
+			if out != tt.want {
+				t.Errorf("TrimBOM\nout:  %q\nwant: %q\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", "''"},
+		{"word", "word"},
+		{"snake_case_123", "snake_case_123"},
+		{"two words", "'two words'"},
+		{"it's", `'it'\''s'`},
+		{"'", `''\'''`},
+		{"$HOME; rm -rf /", "'$HOME; rm -rf /'"},
+		{"a\nb", "'a\nb'"},
+		{"汉语", "'汉语'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := ShellQuote(tt.in)
+			if out != tt.want {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestShortHash(t *testing.T) {
+	tests := []struct {
+		in     string
+		length int
+		want   string
+	}{
+		{"", 0, ""},
+		{"hello", -1, ""},
+		{"hello", 1, ShortHash("hello", 22)[21:]},
+		{"hello", 8, ShortHash("hello", 22)[14:]},
+		{"hello", 100, ShortHash("hello", 22)},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s-%d", tt.in, tt.length), func(t *testing.T) {
+			out := ShortHash(tt.in, tt.length)
+			if out != tt.want {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+		})
+	}
+
+	t.Run("deterministic", func(t *testing.T) {
+		a, b := ShortHash("hello", 10), ShortHash("hello", 10)
+		if a != b {
+			t.Errorf("not deterministic: %q != %q", a, b)
+		}
+		if len(a) != 10 {
+			t.Errorf("wrong length: %q", a)
+		}
+		for _, c := range a {
+			if !strings.ContainsRune(base62, c) {
+				t.Errorf("not base62: %q", a)
+			}
+		}
+	})
+
+	t.Run("different", func(t *testing.T) {
+		seen := make(map[string]string)
+		for i := 0; i < 1000; i++ {
+			in := fmt.Sprintf("input-%d", i)
+			h := ShortHash(in, 8)
+			if prev, ok := seen[h]; ok {
+				t.Errorf("collision for %q and %q: %q", prev, in, h)
+			}
+			seen[h] = in
+		}
+	})
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		in             string
+		terms, phrases []string
+	}{
+		{"", nil, nil},
+		{"   ", nil, nil},
+		{"foo", []string{"foo"}, nil},
+		{" foo  bar\tbaz ", []string{"foo", "bar", "baz"}, nil},
+		{`"foo bar"`, nil, []string{"foo bar"}},
+		{`foo "bar baz" qux`, []string{"foo", "qux"}, []string{"bar baz"}},
+		{`"a b" c "d e"`, []string{"c"}, []string{"a b", "d e"}},
+		{`foo"bar baz"qux`, []string{"foo", "qux"}, []string{"bar baz"}},
+		{`"" foo " "`, []string{"foo"}, nil},
+		{`foo "bar baz`, []string{"foo"}, []string{"bar baz"}},
+		{`foo "`, []string{"foo"}, nil},
+		{`"汉语 漢語" 汉`, []string{"汉"}, []string{"汉语 漢語"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			terms, phrases := ParseQuery(tt.in)
+			if !reflect.DeepEqual(terms, tt.terms) {
+				t.Errorf("terms\nout:  %#v\nwant: %#v\n", terms, tt.terms)
+			}
+			if !reflect.DeepEqual(phrases, tt.phrases) {
+				t.Errorf("phrases\nout:  %#v\nwant: %#v\n", phrases, tt.phrases)
+			}
+		})
+	}
+}
+
+func TestASCII(t *testing.T) {
+	tests := []struct {
+		in    string
+		is    bool
+		ratio float64
+	}{
+		{"", true, 1},
+		{"Hello, world!\n", true, 1},
+		{"\x00\x7f", true, 1},
+		{"café", false, 0.75},
+		{"a汉", false, 0.5},
+		{"汉语漢語", false, 0},
+		{"🖖", false, 0},
+		{"\xff", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if out := IsASCII(tt.in); out != tt.is {
+				t.Errorf("IsASCII: %#v; want %#v", out, tt.is)
+			}
+			if out := ASCIIRatio(tt.in); out != tt.ratio {
+				t.Errorf("ASCIIRatio: %#v; want %#v", out, tt.ratio)
+			}
+		})
+	}
+}
+
+func TestSplitCamel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"word", []string{"word"}},
+		{"Word", []string{"Word"}},
+		{"HTTP", []string{"HTTP"}},
+		{"camelCase", []string{"camel", "Case"}},
+		{"CamelCase", []string{"Camel", "Case"}},
+		{"getHTTPResponseCode", []string{"get", "HTTP", "Response", "Code"}},
+		{"HTTPServer", []string{"HTTP", "Server"}},
+		{"ServeHTTP", []string{"Serve", "HTTP"}},
+		{"userID", []string{"user", "ID"}},
+		{"Base64Encode", []string{"Base64", "Encode"}},
+		{"HTTP2Server", []string{"HTTP2", "Server"}},
+		{"version10", []string{"version10"}},
+		{"a1B2", []string{"a1", "B2"}},
+		{"snake_case-Words", []string{"snake", "case", "Words"}},
+		{"ÜberÄrger", []string{"Über", "Ärger"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := SplitCamel(tt.in)
+			if !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestGravatar(t *testing.T) {
+	tests := []struct {
+		in   string
+		size int
+		hash string
+		url  string
+	}{
+		{"myemailaddress@example.com", 0,
+			"0bc83cb571cd1c50ba6f3e8a78ef1346",
+			"https://www.gravatar.com/avatar/0bc83cb571cd1c50ba6f3e8a78ef1346"},
+		{" MyEmailAddress@example.com ", 80,
+			"0bc83cb571cd1c50ba6f3e8a78ef1346",
+			"https://www.gravatar.com/avatar/0bc83cb571cd1c50ba6f3e8a78ef1346?s=80"},
+		{"", -1,
+			"d41d8cd98f00b204e9800998ecf8427e",
+			"https://www.gravatar.com/avatar/d41d8cd98f00b204e9800998ecf8427e"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if out := GravatarHash(tt.in); out != tt.hash {
+				t.Errorf("GravatarHash\nout:  %s\nwant: %s\n", out, tt.hash)
+			}
+			if out := GravatarURL(tt.in, tt.size); out != tt.url {
+				t.Errorf("GravatarURL\nout:  %s\nwant: %s\n", out, tt.url)
+			}
+		})
+	}
+}
+
+func TestTrimPrefixes(t *testing.T) {
+	tests := []struct {
+		in       string
+		prefixes []string
+		want     string
+	}{
+		{"", nil, ""},
+		{"file.tar.gz", nil, "file.tar.gz"},
+		{"file.tar.gz", []string{"x", "y"}, "file.tar.gz"},
+		{"IMG_1234.jpg", []string{"IMG_"}, "1234.jpg"},
+		{"IMG_1234.jpg", []string{"I", "IMG", "IMG_"}, "1234.jpg"},
+		{"IMG_1234.jpg", []string{"IMG_", "I", "IMG"}, "1234.jpg"},
+		{"aaab", []string{"a"}, "aab"},
+		{"abc", []string{"", "abc"}, ""},
+		{"汉语漢語", []string{"汉", "汉语"}, "漢語"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := TrimPrefixes(tt.in, tt.prefixes...)
+			if out != tt.want {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrimSuffixes(t *testing.T) {
+	tests := []struct {
+		in       string
+		suffixes []string
+		want     string
+	}{
+		{"", nil, ""},
+		{"file.tar.gz", nil, "file.tar.gz"},
+		{"file.tar.gz", []string{".zip", ".bz2"}, "file.tar.gz"},
+		{"file.tar.gz", []string{".gz", ".tar.gz"}, "file"},
+		{"file.tar.gz", []string{".tar.gz", ".gz"}, "file"},
+		{"file.gz.gz", []string{".gz"}, "file.gz"},
+		{"abc", []string{"", "abc"}, ""},
+		{"汉语漢語", []string{"語", "漢語"}, "汉语"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := TrimSuffixes(tt.in, tt.suffixes...)
+			if out != tt.want {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndent(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"a", "> a"},
+		{"a\nb\n", "> a\n> b\n"},
+		{"a\n\nb", "> a\n\n> b"},
+		{"a\r\n\r\nb\r\n", "> a\r\n\r\n> b\r\n"},
+		{"a\n  \n\t\nb", "> a\n  \n\t\n> b"},
+		{"  a\n b", ">   a\n>  b"},
+		{"汉语\n漢語", "> 汉语\n> 漢語"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := Indent(tt.in, "> ")
+			if out != tt.want {
+				t.Errorf("\nout:  %q\nwant: %q\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestDedent(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"a", "a"},
+		{"  a", "a"},
+		{"  a\n  b\n", "a\nb\n"},
+		{"  a\n    b\n  c", "a\n  b\nc"},
+		{"    a\n  b", "  a\nb"},
+		{"  a\n\n  b", "a\n\nb"},
+		{"  a\n \n  b", "a\n\nb"},
+		{"  a\n\t\n  b\n", "a\n\nb\n"},
+		{"\ta\n\t\tb", "a\n\tb"},
+		{"\t  a\n  b", "\t  a\n  b"},
+		{"\t a\n\t b", "a\nb"},
+		{"  a\r\n  b\r\n", "a\r\nb\r\n"},
+		{"  a\r\n  \r\n    b", "a\r\n\r\n  b"},
+		{"  汉语\n    漢語", "汉语\n  漢語"},
+		{"a\n  b", "a\n  b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := Dedent(tt.in)
+			if out != tt.want {
+				t.Errorf("\nout:  %q\nwant: %q\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	text := "The quick brown fox jumps over the lazy dog"
+	tests := []struct {
+		text, term string
+		radius     int
+		want       string
+	}{
+		{text, "fox", 6, "…brown fox jumps…"},
+		{text, "FOX", 6, "…brown fox jumps…"},
+		{text, "the", 4, "The qui…"},
+		{text, "dog", 5, "…lazy dog"},
+		{text, "fox", 100, text},
+		{text, "fox", 0, "…fox…"},
+		{text, "cat", 5, "The quick …"},
+		{text, "", 5, "The quick …"},
+		{"short", "cat", 5, "short"},
+		{"", "cat", 5, ""},
+		{"汉语漢語汉语漢語", "漢語", 1, "…语漢語汉…"},
+		{"Ünïcödé ÜBER alles", "über", 3, "…dé ÜBER al…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.term, func(t *testing.T) {
+			out := Excerpt(tt.text, tt.term, tt.radius)
+			if out != tt.want {
+				t.Errorf("\nout:  %q\nwant: %q\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitials(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"", 0, ""},
+		{"   ", 3, ""},
+		{"John Ronald Reuel", 0, "JRR"},
+		{"John Ronald Reuel Tolkien", 3, "JRR"},
+		{"john ronald reuel", 2, "JR"},
+		{"  John \t  Tolkien\n", 0, "JT"},
+		{"Tolkien", 2, "T"},
+		{"(Grey) Gandalf", 0, "GG"},
+		{"Émile Zola", 0, "ÉZ"},
+		{"Лев Толстой", 0, "ЛТ"},
+		{"毛泽东", 0, "毛泽东"},
+		{"毛泽东", 2, "毛泽"},
+		{"村上 春樹", 0, "村上春樹"},
+		{"Go 言語", 0, "G言語"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := Initials(tt.in, tt.max)
+			if out != tt.want {
+				t.Errorf("\nout:  %q\nwant: %q\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldsN(t *testing.T) {
+	tests := []struct {
+		in, sep string
+		n       int
+		want    []string
+	}{
+		{"", ":", 2, nil},
+		{"  ", ":", 2, nil},
+		{"a:b", ":", 0, nil},
+
+		{"key: value: with: colons", ":", 2, []string{"key", "value: with: colons"}},
+		{"key: value: with: colons", ":", 3, []string{"key", "value", "with: colons"}},
+		{"key: value: with: colons", ":", 4, []string{"key", "value", "with", "colons"}},
+		{"key: value: with: colons", ":", 10, []string{"key", "value", "with", "colons"}},
+		{"key: value: with: colons", ":", -1, []string{"key", "value", "with", "colons"}},
+		{"key: value: with: colons", ":", 1, []string{"key: value: with: colons"}},
+		{"  a ; b  ", ";", 1, []string{"a ; b"}},
+
+		{"a;;b;c", ";", 2, []string{"a", "b;c"}},
+		{";a; ;b;c;", ";", 2, []string{"a", "b;c"}},
+		{"a; b;", ";", 5, []string{"a", "b"}},
+		{";;;", ";", 2, nil},
+		{"a", ";", 2, []string{"a"}},
+		{"a==b==c", "==", 2, []string{"a", "b==c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", tt.in, tt.n), func(t *testing.T) {
+			out := FieldsN(tt.in, tt.sep, tt.n)
+			if !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountFold(t *testing.T) {
+	tests := []struct {
+		s, substr string
+		want      int
+		overlap   int
+	}{
+		{"", "", 1, 1},
+		{"", "a", 0, 0},
+		{"abc", "", 4, 4},
+		{"€€", "", 3, 3},
+		{"abc", "d", 0, 0},
+		{"abc", "abcd", 0, 0},
+
+		{"aaa", "aa", 1, 2},
+		{"aaaa", "aa", 2, 3},
+		{"AaA", "aa", 1, 2},
+		{"abababa", "ABA", 2, 3},
+		{"Hello hello HELLO", "hello", 3, 3},
+
+		{"Straße STRASSE", "straße", 1, 1},
+		{"ΣΑΣ σας", "σας", 2, 2},
+		{"\u212a k K", "k", 3, 3},
+		{"ǅǆǄ", "ǆ", 3, 3},
+		{"ÅåÅ", "å", 3, 3},
+		{"İi", "i", 1, 1},
+		{"KkK", "k", 3, 3}, // Kelvin sign.
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s+"/"+tt.substr, func(t *testing.T) {
+			out := CountFold(tt.s, tt.substr, false)
+			if out != tt.want {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+			out = CountFold(tt.s, tt.substr, true)
+			if out != tt.overlap {
+				t.Errorf("overlap\nout:  %#v\nwant: %#v\n", out, tt.overlap)
+			}
+		})
+	}
+}
+
+func TestSurround(t *testing.T) {
+	tests := []struct {
+		in, left, right, want string
+	}{
+		{"", "", "", ""},
+		{"", "(", ")", "()"},
+		{"a", "(", ")", "(a)"},
+		{"(a)", "(", ")", "((a))"},
+		{"汉语", "「", "」", "「汉语」"},
+		{"x", "<<", ">>", "<<x>>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := Surround(tt.in, tt.left, tt.right)
+			if out != tt.want {
+				t.Errorf("\nout:  %q\nwant: %q\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{``, `""`},
+		{`a`, `"a"`},
+		{`a b`, `"a b"`},
+		{`"a"`, `"\"a\""`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`a\b`, `"a\\b"`},
+		{`a\"b`, `"a\\\"b"`},
+		{`'a'`, `"'a'"`},
+		{"汉语\t€", "\"汉语\t€\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := Quote(tt.in)
+			if out != tt.want {
+				t.Errorf("\nout:  %s\nwant: %s\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteList(t *testing.T) {
+	tests := []struct {
+		in   []string
+		want string
+	}{
+		{nil, ``},
+		{[]string{""}, `""`},
+		{[]string{"a"}, `"a"`},
+		{[]string{"a", "b c"}, `"a", "b c"`},
+		{[]string{`x"y`, "汉语", ""}, `"x\"y", "汉语", ""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			out := QuoteList(tt.in)
+			if out != tt.want {
+				t.Errorf("\nout:  %s\nwant: %s\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateCount(t *testing.T) {
+	tests := []struct {
+		in      string
+		n       int
+		want    string
+		dropped int
+	}{
+		{"", 0, "", 0},
+		{"", 5, "", 0},
+		{"abc", 3, "abc", 0},
+		{"abc", 10, "abc", 0},
+		{"abcdef", 3, "abc", 3},
+		{"abcdef", 0, "", 6},
+		{"abcdef", -1, "", 6},
+		{"汉语漢語", 1, "汉", 3},
+		{"H€łø🖖", 2, "H€", 3},
+		{"H€łø🖖", 5, "H€łø🖖", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", tt.in, tt.n), func(t *testing.T) {
+			out, dropped := TruncateCount(tt.in, tt.n)
+			if out != tt.want || dropped != tt.dropped {
+				t.Errorf("\nout:  %q, %d\nwant: %q, %d\n", out, dropped, tt.want, tt.dropped)
+			}
+		})
+	}
+}
+
+func TestCapitalizeSentences(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"   ", "   "},
+		{"hello", "Hello"},
+		{"Hello", "Hello"},
+		{"  hello world", "  Hello world"},
+		{"hello. world", "Hello. World"},
+		{"hello.   world", "Hello.   World"},
+		{"hello.\n\nworld", "Hello.\n\nWorld"},
+		{"what? yes! no. ok", "What? Yes! No. Ok"},
+		{"wait... what?! really", "Wait... What?! Really"},
+		{`he said "hi." then left`, `He said "hi." Then left`},
+		{"(an aside.) more", "(An aside.) More"},
+		{"pi is 3.14 or so. next", "Pi is 3.14 or so. Next"},
+		{"see example.com for more", "See example.com for more"},
+		{"fruit, e.g. apples. also pears", "Fruit, e.g. apples. Also pears"},
+		{"ask dr. smith. he knows", "Ask dr. smith. He knows"},
+		{"leave the REST ALONE. iPhone", "Leave the REST ALONE. IPhone"},
+		{"42 is the answer. yes", "42 is the answer. Yes"},
+		{"élan. über. ωμέγα", "Élan. Über. Ωμέγα"},
+		{"end.", "End."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := CapitalizeSentences(tt.in)
+			if out != tt.want {
+				t.Errorf("\nout:  %q\nwant: %q\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestContainsFold(t *testing.T) {
+	tests := []struct {
+		list []string
+		in   string
+		want int
+	}{
+		{nil, "", -1},
+		{[]string{}, "a", -1},
+		{[]string{""}, "", 0},
+		{[]string{"a", "b"}, "c", -1},
+		{[]string{"a", "b"}, "b", 1},
+		{[]string{"Content-Type", "Accept"}, "content-type", 0},
+		{[]string{"Content-Type", "Accept"}, "ACCEPT", 1},
+		{[]string{"accept", "Accept"}, "ACCEPT", 0},
+		{[]string{"ab"}, "a", -1},
+		{[]string{"Straße"}, "STRASSE", -1},
+		{[]string{"x", "ΣΑΣ"}, "σας", 1},
+		{[]string{"ÅNGSTRÖM"}, "ångström", 0},
+		{[]string{"k"}, "K", 0}, // Kelvin sign.
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v/%s", tt.list, tt.in), func(t *testing.T) {
+			out := IndexFold(tt.list, tt.in)
+			if out != tt.want {
+				t.Errorf("IndexFold\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+			if c := ContainsFold(tt.list, tt.in); c != (tt.want > -1) {
+				t.Errorf("ContainsFold\nout:  %#v\nwant: %#v\n", c, tt.want > -1)
+			}
+		})
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr string
+	}{
+		{``, nil, ""},
+		{`   `, nil, ""},
+		{`a`, []string{"a"}, ""},
+		{`  a   b	c  `, []string{"a", "b", "c"}, ""},
+		{"a\nb", []string{"a", "b"}, ""},
+		{`a "b c" 'd e'`, []string{"a", "b c", "d e"}, ""},
+		{`a"b c"d`, []string{"ab cd"}, ""},
+		{`"" ''`, []string{"", ""}, ""},
+		{`a ""`, []string{"a", ""}, ""},
+
+		// Nested quote types.
+		{`"it's"`, []string{"it's"}, ""},
+		{`'say "hi"'`, []string{`say "hi"`}, ""},
+		{`"a 'b' c" 'd "e" f'`, []string{`a 'b' c`, `d "e" f`}, ""},
+
+		// Escapes.
+		{`a\ b`, []string{"a b"}, ""},
+		{`\"a\"`, []string{`"a"`}, ""},
+		{`"a \"b\" c"`, []string{`a "b" c`}, ""},
+		{`"a\\b"`, []string{`a\b`}, ""},
+		{`"a\nb"`, []string{`a\nb`}, ""},
+		{`'a\b'`, []string{`a\b`}, ""},
+		{`'a\'`, []string{`a\`}, ""},
+		{`\\`, []string{`\`}, ""},
+		{`\'`, []string{`'`}, ""},
+		{`"汉语 €" ü`, []string{"汉语 €", "ü"}, ""},
+
+		// Errors.
+		{`"abc`, nil, `unterminated " quote`},
+		{`'abc`, nil, `unterminated ' quote`},
+		{`a "b' c`, nil, `unterminated " quote`},
+		{`"a\"`, nil, `unterminated " quote`},
+		{`a\`, nil, "trailing backslash"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out, err := Tokenize(tt.in)
+			if !ztest.ErrorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nout:  %v\nwant: %v\n", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+		})
+	}
+
+	t.Run("ShellQuote", func(t *testing.T) {
+		want := []string{"", "a b", "it's", `"x"`, `a\b`, "汉语"}
+		q := make([]string, 0, len(want))
+		for _, w := range want {
+			q = append(q, ShellQuote(w))
+		}
+		out, err := Tokenize(strings.Join(q, " "))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(out, want) {
+			t.Errorf("\nout:  %#v\nwant: %#v\n", out, want)
+		}
+	})
+}
+
+func TestChunk(t *testing.T) {
+	tests := []struct {
+		in   []string
+		size int
+		want [][]string
+	}{
+		{nil, 2, nil},
+		{[]string{}, 2, nil},
+		{[]string{"a"}, 2, [][]string{{"a"}}},
+		{[]string{"a", "b", "c", "d"}, 2, [][]string{{"a", "b"}, {"c", "d"}}},
+		{[]string{"a", "b", "c", "d", "e"}, 2, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}},
+		{[]string{"a", "b", "c"}, 10, [][]string{{"a", "b", "c"}}},
+		{[]string{"a", "b", "c"}, 0, [][]string{{"a", "b", "c"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v/%d", tt.in, tt.size), func(t *testing.T) {
+			out := Chunk(tt.in, tt.size)
+			if !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestChunkRunes(t *testing.T) {
+	tests := []struct {
+		in   string
+		size int
+		want []string
+	}{
+		{"", 2, nil},
+		{"a", 2, []string{"a"}},
+		{"abcd", 2, []string{"ab", "cd"}},
+		{"abcde", 2, []string{"ab", "cd", "e"}},
+		{"abc", 10, []string{"abc"}},
+		{"abc", 0, []string{"abc"}},
+		{"abc", -1, []string{"abc"}},
+		{"汉语漢語汉", 2, []string{"汉语", "漢語", "汉"}},
+		{"H€łø🖖", 2, []string{"H€", "łø", "🖖"}},
+		{"🖖🖖🖖", 1, []string{"🖖", "🖖", "🖖"}},
+		{"a\xffb", 2, []string{"a\xff", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", tt.in, tt.size), func(t *testing.T) {
+			out := ChunkRunes(tt.in, tt.size)
+			if !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	tests := []struct {
+		in   int
+		want string
+	}{
+		{0, "0th"}, {1, "1st"}, {2, "2nd"}, {3, "3rd"}, {4, "4th"}, {10, "10th"},
+		{11, "11th"}, {12, "12th"}, {13, "13th"}, {14, "14th"},
+		{21, "21st"}, {22, "22nd"}, {23, "23rd"}, {101, "101st"},
+		{111, "111th"}, {112, "112th"}, {113, "113th"}, {1002, "1002nd"},
+		{-1, "-1st"}, {-12, "-12th"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			out := Ordinal(tt.in)
+			if out != tt.want {
+				t.Errorf("\nout:  %q\nwant: %q\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestPluralize(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"", 2, ""},
+		{"cat", 1, "cat"},
+		{"cat", -1, "cat"},
+		{"cat", 0, "cats"},
+		{"cat", 2, "cats"},
+		{"bus", 2, "buses"},
+		{"box", 2, "boxes"},
+		{"buzz", 2, "buzzes"},
+		{"church", 2, "churches"},
+		{"dish", 2, "dishes"},
+		{"city", 2, "cities"},
+		{"day", 2, "days"},
+		{"photo", 2, "photos"},
+
+		{"child", 2, "children"},
+		{"person", 3, "people"},
+		{"mouse", 2, "mice"},
+		{"knife", 2, "knives"},
+		{"sheep", 2, "sheep"},
+		{"person", 1, "person"},
+
+		{"Cat", 2, "Cats"},
+		{"CAT", 2, "CATS"},
+		{"Child", 2, "Children"},
+		{"CHILD", 2, "CHILDREN"},
+		{"City", 2, "Cities"},
+		{"CITY", 2, "CITIES"},
+		{"iPhone", 2, "iPhones"},
+		{"McDonald", 2, "McDonalds"},
+		{"ID", 2, "IDS"},
+		{"URL", 2, "URLS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", tt.in, tt.n), func(t *testing.T) {
+			out := Pluralize(tt.in, tt.n)
+			if out != tt.want {
+				t.Errorf("\nout:  %q\nwant: %q\n", out, tt.want)
+			}
+		})
+	}
+
+	t.Run("override", func(t *testing.T) {
+		Irregular["octopus"] = "octopodes"
+		defer delete(Irregular, "octopus")
+
+		if out := Pluralize("octopus", 2); out != "octopodes" {
+			t.Errorf("\nout:  %q\nwant: %q\n", out, "octopodes")
+		}
+	})
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		in                 string
+		keepStart, keepEnd int
+		maskRune           rune
+		want               string
+	}{
+		{"", 2, 2, '*', ""},
+		{"4111111111111111", 0, 4, '*', "************1111"},
+		{"4111111111111111", 4, 4, '#', "4111########1111"},
+		{"secret-token", 3, 0, '*', "sec*********"},
+		{"secret", 0, 0, '*', "******"},
+		{"secret", -1, -1, '*', "******"},
+		{"secret", 2, 2, '•', "se••et"},
+
+		// Short strings are masked entirely.
+		{"abc", 2, 2, '*', "***"},
+		{"abcd", 2, 2, '*', "****"},
+		{"abcde", 2, 2, '*', "ab*de"},
+		{"a", 1, 0, '*', "*"},
+
+		{"汉语漢語汉语", 1, 1, '*', "汉****语"},
+		{"H€łø🖖", 1, 1, '█', "H███🖖"},
+		{"🖖🖖", 1, 1, '*', "**"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d/%d", tt.in, tt.keepStart, tt.keepEnd), func(t *testing.T) {
+			out := Mask(tt.in, tt.keepStart, tt.keepEnd, tt.maskRune)
+			if out != tt.want {
+				t.Errorf("\nout:  %q\nwant: %q\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskEmail(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"martin@example.com", "m*****@example.com"},
+		{"a@example.com", "*@example.com"},
+		{"ab@example.com", "a*@example.com"},
+		{"@example.com", "@example.com"},
+		{`"a@b"@example.com`, `"****@example.com`},
+		{"mårtin@exämple.com", "m*****@exämple.com"},
+		{"not-an-email", "n***********"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := MaskEmail(tt.in)
+			if out != tt.want {
+				t.Errorf("\nout:  %q\nwant: %q\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"", "", false},
+		{"", "a", true},
+		{"a", "", false},
+		{"a", "b", true},
+		{"b", "a", false},
+		{"a", "a", false},
+		{"1", "2", true},
+		{"2", "10", true},
+		{"10", "2", false},
+		{"file2", "file10", true},
+		{"file10", "file2", false},
+		{"file10", "file10", false},
+		{"file", "file1", true},
+		{"file1", "file", false},
+		{"file1a", "file1b", true},
+		{"file1b", "file01a", false},
+		{"1", "01", true},
+		{"01", "1", false},
+		{"01", "001", true},
+		{"file01b", "file1c", true},
+		{"a001b", "a1a", false},
+		{"v1.9.0", "v1.10.0", true},
+		{"v1.10.0", "v1.9.0", false},
+		{"x99999999999999999999999", "x100000000000000000000000", true},
+		{"abc1", "abd", true},
+		{"a1", "a 1", false},
+		{"ä2", "ä10", true},
+		{"汉语2", "汉语10", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"<"+tt.b, func(t *testing.T) {
+			out := NaturalLess(tt.a, tt.b)
+			if out != tt.want {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortNatural(t *testing.T) {
+	tests := []struct {
+		in, want []string
+	}{
+		{nil, nil},
+		{
+			[]string{"file10", "file2", "file1", "file20", "file3"},
+			[]string{"file1", "file2", "file3", "file10", "file20"},
+		},
+		{
+			[]string{"img12.png", "img10.png", "IMG2.png", "img2.png", "img1.png"},
+			[]string{"IMG2.png", "img1.png", "img2.png", "img10.png", "img12.png"},
+		},
+		{
+			[]string{"a02", "a1", "a2", "a01", "a10"},
+			[]string{"a1", "a01", "a2", "a02", "a10"},
+		},
+		{
+			[]string{"1.10", "1.2", "1.1", "2.0", "1.02"},
+			[]string{"1.1", "1.2", "1.02", "1.10", "2.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v", tt.in), func(t *testing.T) {
+			SortNatural(tt.in)
+			if !reflect.DeepEqual(tt.in, tt.want) {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", tt.in, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandRange(t *testing.T) {
+	tests := []struct {
+		in       string
+		sortUniq bool
+		want     []int
+		wantErr  string
+	}{
+		{"", false, nil, ""},
+		{" , ,", false, nil, ""},
+		{"5", false, []int{5}, ""},
+		{"1-3", false, []int{1, 2, 3}, ""},
+		{"1-3,5,7-8", false, []int{1, 2, 3, 5, 7, 8}, ""},
+		{" 1 - 3 , 5 ,\t7-8 ,", false, []int{1, 2, 3, 5, 7, 8}, ""},
+		{"4-4", false, []int{4}, ""},
+		{"-2-1", false, []int{-2, -1, 0, 1}, ""},
+		{"-5--3", false, []int{-5, -4, -3}, ""},
+		{"7,1-3,2", false, []int{7, 1, 2, 3, 2}, ""},
+		{"7,1-3,2", true, []int{1, 2, 3, 7}, ""},
+		{"5-6,1-8,3", true, []int{1, 2, 3, 4, 5, 6, 7, 8}, ""},
+
+		{"3-1", false, nil, "higher than end"},
+		{"a", false, nil, `invalid number "a"`},
+		{"1-b", false, nil, `invalid number "b"`},
+		{"1-", false, nil, `invalid number ""`},
+		{"1-2-3", false, nil, `invalid number "2-3"`},
+		{"1.5", false, nil, `invalid number "1.5"`},
+		{"1;2", false, nil, `invalid number "1;2"`},
+		{"0-9999999999", false, nil, "has more than"},
+		{"0-524287,524288-1048575,1048576", false, nil, "list has more than"},
+		{strings.Repeat("0-999999,", 100), true, nil, "list has more than"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%t", tt.in, tt.sortUniq), func(t *testing.T) {
+			out, err := ExpandRange(tt.in, tt.sortUniq)
+			if !ztest.ErrorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nout:  %v\nwant: %v\n", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommonPrefix(t *testing.T) {
+	tests := []struct {
+		in             []string
+		prefix, suffix string
+	}{
+		{nil, "", ""},
+		{[]string{}, "", ""},
+		{[]string{""}, "", ""},
+		{[]string{"abc"}, "abc", "abc"},
+		{[]string{"abc", "abc"}, "abc", "abc"},
+		{[]string{"abc", ""}, "", ""},
+		{[]string{"abc", "xyz"}, "", ""},
+		{[]string{"abc", "abd"}, "ab", ""},
+		{[]string{"abc", "ab"}, "ab", ""},
+		{[]string{"xbc", "abc"}, "", "bc"},
+		{[]string{"/home/a/file.go", "/home/b/file.go", "/home/ab/x_file.go"}, "/home/", "file.go"},
+		{[]string{"test1", "test2", "other3"}, "", ""},
+
+		// "€" is e2 82 ac and "₭" is e2 82 ad; the first two bytes are
+		// identical.
+		{[]string{"a€", "a₭"}, "a", ""},
+		{[]string{"€a", "₭a"}, "", "a"},
+		// "ä" is c3 a4 and "ɤ" is c9 a4; the last byte is identical.
+		{[]string{"ä", "ɤ"}, "", ""},
+		{[]string{"xä", "yɤ"}, "", ""},
+		{[]string{"汉语漢語", "汉语汉语"}, "汉语", ""},
+		{[]string{"汉语漢語", "漢語漢語"}, "", "漢語"},
+		{[]string{"H€łø🖖", "H€łø🖖"}, "H€łø🖖", "H€łø🖖"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.in), func(t *testing.T) {
+			out := CommonPrefix(tt.in)
+			if out != tt.prefix {
+				t.Errorf("prefix\nout:  %q\nwant: %q\n", out, tt.prefix)
+			}
+			out = CommonSuffix(tt.in)
+			if out != tt.suffix {
+				t.Errorf("suffix\nout:  %q\nwant: %q\n", out, tt.suffix)
+			}
+		})
+	}
+}
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		in, start, end string
+		want           string
+		all            []string
+	}{
+		{"", "(", ")", "", nil},
+		{"abc", "(", ")", "", nil},
+		{"a(bc", "(", ")", "", nil},
+		{"a)b(c", "(", ")", "", nil},
+		{"a(b)c", "(", ")", "b", []string{"b"}},
+		{"()", "(", ")", "", []string{""}},
+		{"(a)(b) (c", "(", ")", "a", []string{"a", "b"}},
+		{"((a))", "(", ")", "(a", []string{"(a"}},
+		{"x: 1; y: 2;", ": ", ";", "1", []string{"1", "2"}},
+		{"{{a}} and {{b}}", "{{", "}}", "a", []string{"a", "b"}},
+		{"<b>x</b><b>y</b>", "<b>", "</b>", "x", []string{"x", "y"}},
+		{"|a|b|c|", "|", "|", "a", []string{"a", "c"}},
+		{"«汉语» «€»", "«", "»", "汉语", []string{"汉语", "€"}},
+		{"abc", "", "", "", nil},
+		{"abc", "", "b", "a", []string{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := Between(tt.in, tt.start, tt.end)
+			if out != tt.want {
+				t.Errorf("Between\nout:  %q\nwant: %q\n", out, tt.want)
+			}
+			all := AllBetween(tt.in, tt.start, tt.end)
+			if !reflect.DeepEqual(all, tt.all) {
+				t.Errorf("AllBetween\nout:  %#v\nwant: %#v\n", all, tt.all)
+			}
+		})
+	}
+}
+
+func TestLeftHTML(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want template.HTML
+	}{
+		{"", 5, ""},
+		{"hello", 5, "hello"},
+		{"hello", 10, "hello"},
+		{"hello world", 5, "hello…"},
+		{"hello world", 0, "…"},
+		{"<b>hello</b>", 5, "<b>hello</b>"},
+		{"<b>hello</b> world", 5, "<b>hello</b>…"},
+		{"<b>hello world</b>", 5, "<b>hello…</b>"},
+		{"<p>a <b>bold <i>and italic</i> text</b> here</p>", 8, "<p>a <b>bold <i>a…</i></b></p>"},
+		{"<p>a <b>bold <i>and italic</i> text</b> here</p>", 20, "<p>a <b>bold <i>and italic</i> te…</b></p>"},
+		{`<a href="/x?a=1&amp;b=2" title="a > b">link text</a>`, 4, `<a href="/x?a=1&amp;b=2" title="a > b">link…</a>`},
+
+		// Entities count as one character and are never split.
+		{"a &amp; b", 3, "a &amp;…"},
+		{"a &amp; b", 2, "a …"},
+		{"&lt;tag&gt; x", 5, "&lt;tag&gt;…"},
+		{"&#8364;&#x20AC;€ x", 3, "&#8364;&#x20AC;€…"},
+		{"fish & chips", 6, "fish &…"},
+		{"a&b; c", 2, "a&b;…"},
+
+		// Void and self-closing elements, comments.
+		{"a<br>b<img src=x>c<br/>d<hr />e", 3, "a<br>b<img src=x>c<br/>…"},
+		{"<div/>abc", 1, "<div/>a…"},
+		{"<!-- <b> -->abc", 1, "<!-- <b> -->a…"},
+		{"<!DOCTYPE html><p>abc</p>", 1, "<!DOCTYPE html><p>a…</p>"},
+		{"<UL><LI>one<LI>two</UL>", 4, "<UL><LI>one<LI>t…</li></li></ul>"},
+
+		{"<p>汉语漢語</p>", 2, "<p>汉语…</p>"},
+		{"a < b", 2, "a …"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", tt.in, tt.n), func(t *testing.T) {
+			out := LeftHTML(tt.in, tt.n)
+			if out != tt.want {
+				t.Errorf("\nout:  %q\nwant: %q\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountWords(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", 0},
+		{"   ", 0},
+		{"...!?", 0},
+		{"hello", 1},
+		{"hello world", 2},
+		{"  hello,world!  ", 2},
+		{"Hello—world; (it's) a well-known fact.", 6},
+		{"don’t stop", 2},
+		{"a - b", 2},
+		{"trailing- 'quoted'", 2},
+		{"It costs $4.99, or 5€.", 5},
+		{"e.g. i.e.", 4},
+		{"naïve café über", 3},
+		{"café", 1},
+		{"Привет, мир!", 2},
+		{"汉语漢語", 4},
+		{"日本語のテキスト", 8},
+		{"Go言語 is fun", 5},
+		{"안녕하세요 세계", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := CountWords(tt.in)
+			if out != tt.want {
+				t.Errorf("\nout:  %d\nwant: %d\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	tests := []struct {
+		in   string
+		wpm  int
+		want time.Duration
+	}{
+		{"", 200, 0},
+		{strings.Repeat("word ", 200), 200, time.Minute},
+		{strings.Repeat("word ", 200), 0, time.Minute},
+		{strings.Repeat("word ", 200), -1, time.Minute},
+		{strings.Repeat("word ", 100), 200, 30 * time.Second},
+		{strings.Repeat("word ", 1000), 250, 4 * time.Minute},
+		{"one two three", 60, 3 * time.Second},
+		{"one", 7, 9 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d", tt.wpm), func(t *testing.T) {
+			out := ReadingTime(tt.in, tt.wpm)
+			if out != tt.want {
+				t.Errorf("\nout:  %s\nwant: %s\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitKV(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    map[string]string
+		ordered [][2]string
+		wantErr string
+	}{
+		{"", map[string]string{}, [][2]string{}, ""},
+		{"a=1", map[string]string{"a": "1"}, [][2]string{{"a", "1"}}, ""},
+		{"a=1,b=2", map[string]string{"a": "1", "b": "2"}, [][2]string{{"a", "1"}, {"b", "2"}}, ""},
+		{" a = 1 ,, b=2 , ", map[string]string{"a": "1", "b": "2"}, [][2]string{{"a", "1"}, {"b", "2"}}, ""},
+		{"a=,b= ", map[string]string{"a": "", "b": ""}, [][2]string{{"a", ""}, {"b", ""}}, ""},
+		{"a=1=2", map[string]string{"a": "1=2"}, [][2]string{{"a", "1=2"}}, ""},
+		{"a=1,b=2,a=3", map[string]string{"a": "3", "b": "2"}, [][2]string{{"a", "1"}, {"b", "2"}, {"a", "3"}}, ""},
+		{"ключ=значение", map[string]string{"ключ": "значение"}, [][2]string{{"ключ", "значение"}}, ""},
+
+		{"a", nil, nil, `no "=" in "a"`},
+		{"a=1,b", nil, nil, `no "=" in "b"`},
+		{"a=1,=2", nil, nil, `empty key in "=2"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out, err := SplitKV(tt.in, ",", "=")
+			if !ztest.ErrorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nout:  %v\nwant: %v\n", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+
+			ordered, err := SplitKVOrdered(tt.in, ",", "=")
+			if !ztest.ErrorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nout:  %v\nwant: %v\n", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(ordered, tt.ordered) {
+				t.Errorf("ordered\nout:  %#v\nwant: %#v\n", ordered, tt.ordered)
+			}
+		})
+	}
+
+	t.Run("separators", func(t *testing.T) {
+		out, err := SplitKV("env: prod; team: web", ";", ":")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]string{"env": "prod", "team": "web"}
+		if !reflect.DeepEqual(out, want) {
+			t.Errorf("\nout:  %#v\nwant: %#v\n", out, want)
+		}
+	})
+}
+
+func TestTrimPrefixFold(t *testing.T) {
+	tests := []struct {
+		in, affix      string
+		prefix, suffix string
+	}{
+		{"", "", "", ""},
+		{"abc", "", "abc", "abc"},
+		{"", "a", "", ""},
+		{"a", "ab", "a", "a"},
+		{"Bearer xyz", "bearer ", "xyz", "Bearer xyz"},
+		{"BEARER xyz", "Bearer ", "xyz", "BEARER xyz"},
+		{"bearer xyz", "Bearer ", "xyz", "bearer xyz"},
+		{"Token xyz", "bearer ", "Token xyz", "Token xyz"},
+		{"photo.JPG", ".jpg", "photo.JPG", "photo"},
+		{"ABCabc", "abc", "abc", "ABC"},
+		{"ÜBERüber", "über", "über", "ÜBER"},
+		{"ΣΑΣσας", "σας", "σας", "ΣΑΣ"},
+		{"Kkelvin", "kk", "elvin", "Kkelvin"}, // Kelvin sign
+		{"xk", "K", "xk", "x"},                // Kelvin sign
+		{"汉语漢語", "汉语", "漢語", "汉语漢語"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in+"/"+tt.affix, func(t *testing.T) {
+			out := TrimPrefixFold(tt.in, tt.affix)
+			if out != tt.prefix {
+				t.Errorf("prefix\nout:  %q\nwant: %q\n", out, tt.prefix)
+			}
+			out = TrimSuffixFold(tt.in, tt.affix)
+			if out != tt.suffix {
+				t.Errorf("suffix\nout:  %q\nwant: %q\n", out, tt.suffix)
+			}
+		})
+	}
+}
+
+func TestDedupAdjacent(t *testing.T) {
+	tests := []struct {
+		in, want []string
+	}{
+		{nil, nil},
+		{[]string{}, []string{}},
+		{[]string{"a"}, []string{"a"}},
+		{[]string{"a", "a"}, []string{"a"}},
+		{[]string{"a", "b"}, []string{"a", "b"}},
+		{[]string{"a", "a", "b", "a"}, []string{"a", "b", "a"}},
+		{[]string{"b", "a", "b", "a"}, []string{"b", "a", "b", "a"}},
+		{[]string{"", "", "a", "", ""}, []string{"", "a", ""}},
+		{[]string{"x", "x", "x", "y", "y", "x"}, []string{"x", "y", "x"}},
+		{[]string{"a", "A", "a"}, []string{"a", "A", "a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v", tt.in), func(t *testing.T) {
+			in := make([]string, len(tt.in))
+			copy(in, tt.in)
+			out := DedupAdjacent(tt.in)
+			if !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("\nout:  %#v\nwant: %#v\n", out, tt.want)
+			}
+			if !reflect.DeepEqual(tt.in, in) && tt.in != nil {
+				t.Errorf("input modified\nout:  %#v\nwant: %#v\n", tt.in, in)
+			}
+		})
+	}
+}
+
+func TestStripZeroWidth(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"hello", "hello"},
+		{"he\u200bllo", "hello"},
+		{"\u200b\u200c\u200d\u2060\ufeff", ""},
+		{"\ufeffadmin\u200d", "admin"},
+		{"a\u200c b", "a b"},
+		{"汉\u200b语", "汉语"},
+		{"a\u00a0b", "a\u00a0b"}, // Not zero-width.
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := StripZeroWidth(tt.in)
+			if out != tt.want {
+				t.Errorf("\nout:  %q\nwant: %q\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasHomoglyphs(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"", false},
+		{"paypal", false},
+		{"PayPal123", false},
+		{"café", false},
+		{"Привет", false},
+		{"Ελληνικά", false},
+		{"汉语", false},
+		{"user_汉语", false},
+		{"42", false},
+
+		{"pаypal", true},   // Cyrillic а
+		{"аdmin", true},    // Cyrillic а
+		{"Gοοgle", true},   // Greek ο
+		{"ΑPPLE", true},    // Greek Α
+		{"раураl", true},   // All Cyrillic except l
+		{"раура", true},    // All Cyrillic lookalikes
+		{"сосо", true},     // All Cyrillic lookalikes
+		{"ТОР", true},      // All Cyrillic lookalikes
+		{"Привет a", true}, // Mixed, even if separate words.
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := HasHomoglyphs(tt.in)
+			if out != tt.want {
+				t.Errorf("\nout:  %t\nwant: %t\n", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		text, term, want string
+	}{
+		{"", "", ""},
+		{"", "go", ""},
+		{"hello", "", "hello"},
+		{"hello", "go", "hello"},
+		{"go", "go", "<go>"},
+		{"Go go GO", "go", "<Go> <go> <GO>"},
+		{"Gopher", "GO", "<Go>pher"},
+		{"the cat sat on the mat", "at", "the c<at> s<at> on the m<at>"},
+		{"aaaa", "aa", "<aa><aa>"},
+		{"aaa", "aa", "<aa>a"},
+		{"ÜBER über Über", "über", "<ÜBER> <über> <Über>"},
+		{"汉语漢語汉语", "汉语", "<汉语>漢語<汉语>"},
+		{"ΣΑΣ σας", "σας", "<ΣΑΣ> <σας>"},
+		{"\u212aelvin kelvin", "KELVIN", "<\u212aelvin> <kelvin>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text+"/"+tt.term, func(t *testing.T) {
+			out := Highlight(tt.text, tt.term, "<", ">")
+			if out != tt.want {
+				t.Errorf("\nout:  %q\nwant: %q\n", out, tt.want)
+			}
+		})
+	}
+
+	t.Run("mark", func(t *testing.T) {
+		out := Highlight("Search for Go", "go", "<mark>", "</mark>")
+		want := "Search for <mark>Go</mark>"
+		if out != want {
+			t.Errorf("\nout:  %q\nwant: %q\n", out, want)
+		}
+	})
+}